@@ -12,14 +12,22 @@ const (
 	spanish            = "Spanish"
 	french             = "French"
 	frenchHelloPrefix  = "Bonjour"
+	// what Mellow puts between the prefix and the name, nothing so far (the tests want "HeyCarol")
+	defaultSeparator = ""
 )
 
 func Mellow(name string, language string) string {
+	return MellowWith(name, language, defaultSeparator)
+}
+
+// MellowWith is Mellow where the caller picks what goes between the greeting and the name
+// " " gives "Hey Carol", ", " gives "Hey, Carol" and so on
+func MellowWith(name, language, separator string) string {
 	if name == "" {
 		name = "World" // creating a case where the World is the suffix when nothing given
 	}
 
-	return greetingPrefix(language) + name
+	return greetingPrefix(language) + separator + name
 }
 
 func main() {
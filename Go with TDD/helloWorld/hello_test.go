@@ -1,33 +1,51 @@
 package main
 
-import "testing"
+import (
+	"gopractice/testutil"
+	"testing"
+)
 
 func TestHello(t *testing.T) {
 	t.Run("saying hello to people", func(t *testing.T) {
 		got := Mellow("Carol", "English")
 		want := "HeyCarol"
-		asserCorrectMessage(t, got, want)
+		testutil.AssertEqual(t, got, want)
 
 	})
 	t.Run("say hello world when the string is not supplied", func(t *testing.T) {
 		got := Mellow("", "English")
 		want := "HeyWorld"
-		asserCorrectMessage(t, got, want)
+		testutil.AssertEqual(t, got, want)
 	})
 
 	t.Run("in Spanish", func(t *testing.T) {
 
 		got := Mellow("Elodie", "Spanish")
 		want := "HolaElodie"
-		asserCorrectMessage(t, got, want)
+		testutil.AssertEqual(t, got, want)
 	})
 
 }
 
-// wrapping ourcode to make code more readable
-func asserCorrectMessage(t testing.TB, got, want string) {
-	t.Helper()
-	if got != want {
-		t.Errorf("got %q want %q", got, want)
+func TestMellowWith(t *testing.T) {
+	cases := []struct {
+		name      string
+		language  string
+		separator string
+		want      string
+	}{
+		{"Carol", "English", "", "HeyCarol"},
+		{"Carol", "English", " ", "Hey Carol"},
+		{"Carol", "English", ", ", "Hey, Carol"},
+		{"Carol", "English", " - ", "Hey - Carol"},
+		{"Elodie", "French", " ", "Bonjour Elodie"},
+		{"", "Spanish", ", ", "Hola, World"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.want, func(t *testing.T) {
+			got := MellowWith(tc.name, tc.language, tc.separator)
+			testutil.AssertEqual(t, got, tc.want)
+		})
 	}
 }
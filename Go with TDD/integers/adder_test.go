@@ -1,21 +1,118 @@
 package integers
 
-import "testing"
+import (
+	"errors"
+	"gopractice/testutil"
+	"math"
+	"testing"
+)
 
 func TestAdder(t *testing.T) {
 
 	sum := Add(2, 2)
 	expected := 4
 
-	assertCorrectMessage(t, sum, expected)
+	testutil.AssertEqual(t, sum, expected)
 }
 
-func assertCorrectMessage(t testing.TB, sum, expected int) {
-	t.Helper()
+func TestAddAll(t *testing.T) {
+	cases := []struct {
+		name string
+		nums []int
+		want int
+	}{
+		{"no arguments", nil, 0},
+		{"one argument", []int{7}, 7},
+		{"several arguments", []int{1, 2, 3, 4}, 10},
+		{"negatives", []int{5, -8, 1}, -2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			testutil.AssertEqual(t, AddAll(tc.nums...), tc.want)
+		})
+	}
+}
+
+func TestAddChecked(t *testing.T) {
+	t.Run("normal add", func(t *testing.T) {
+		sum, err := AddChecked(2, 2)
+		testutil.AssertNoError(t, err)
+
+		testutil.AssertEqual(t, sum, 4)
+	})
+
+	t.Run("overflowing add near math.MaxInt", func(t *testing.T) {
+		_, err := AddChecked(math.MaxInt, 1)
+
+		if !errors.Is(err, ErrOverflow) {
+			t.Errorf("got %v, want ErrOverflow", err)
+		}
+	})
+
+	t.Run("underflowing add near math.MinInt", func(t *testing.T) {
+		_, err := AddChecked(math.MinInt, -1)
+
+		if !errors.Is(err, ErrOverflow) {
+			t.Errorf("got %v, want ErrOverflow", err)
+		}
+	})
 
-	if sum != expected {
-		t.Errorf("expected , '%d' but got '%d' ", sum, expected)
+	t.Run("right at the edge is fine", func(t *testing.T) {
+		sum, err := AddChecked(math.MaxInt-1, 1)
+		testutil.AssertNoError(t, err)
 
+		testutil.AssertEqual(t, sum, math.MaxInt)
+	})
+}
+
+func TestCalculator(t *testing.T) {
+	cases := []struct {
+		name string
+		op   func(x, y int) int
+		x, y int
+		want int
+	}{
+		{"subtract", Subtract, 5, 3, 2},
+		{"subtract below zero", Subtract, 3, 5, -2},
+		{"multiply", Multiply, 4, 3, 12},
+		{"multiply by zero", Multiply, 4, 0, 0},
+		{"multiply negatives", Multiply, -4, -3, 12},
 	}
 
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			testutil.AssertEqual(t, tc.op(tc.x, tc.y), tc.want)
+		})
+	}
+}
+
+func TestDivide(t *testing.T) {
+	cases := []struct {
+		name    string
+		x, y    int
+		want    int
+		wantErr error
+	}{
+		{"even split", 12, 4, 3, nil},
+		{"remainder dropped", 7, 2, 3, nil},
+		{"negative truncates toward zero", -7, 2, -3, nil},
+		{"divide by zero", 7, 0, 0, ErrDivideByZero},
+		{"MinInt by -1 overflows", math.MinInt, -1, 0, ErrOverflow},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Divide(tc.x, tc.y)
+
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("got %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			testutil.AssertNoError(t, err)
+			testutil.AssertEqual(t, got, tc.want)
+		})
+	}
 }
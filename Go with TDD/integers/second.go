@@ -1,12 +1,62 @@
 package integers
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrOverflow is what AddChecked returns when the real sum does not fit in an int
+var ErrOverflow = errors.New("integer overflow")
+
+// ErrDivideByZero is what Divide returns instead of panicking like a plain x / 0 would
+var ErrDivideByZero = errors.New("division by zero")
 
 func Add(x, y int) int {
 
 	return x + y
 }
 
+// AddAll is Add for any number of ints, nothing at all sums to 0
+// slice_arr.Sum does the same for a slice you already have, this is for loose arguments
+func AddAll(nums ...int) int {
+	sum := 0
+	for _, n := range nums {
+		sum += n
+	}
+	return sum
+}
+
+// AddChecked is Add that refuses to wrap around
+// plain Add(math.MaxInt, 1) silently gives you math.MinInt, this gives you an error instead
+func AddChecked(x, y int) (int, error) {
+	// check before adding, after the add the damage is already done
+	if (y > 0 && x > math.MaxInt-y) || (y < 0 && x < math.MinInt-y) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrOverflow, x, y)
+	}
+	return x + y, nil
+}
+
+func Subtract(x, y int) int {
+	return x - y
+}
+
+func Multiply(x, y int) int {
+	return x * y
+}
+
+// Divide is integer division, the remainder is dropped (7 / 2 is 3, -7 / 2 is -3)
+// it errors on a zero divisor and on math.MinInt / -1, the one quotient that doesn't fit in an int
+func Divide(x, y int) (int, error) {
+	if y == 0 {
+		return 0, fmt.Errorf("%w: %d / 0", ErrDivideByZero, x)
+	}
+	if x == math.MinInt && y == -1 {
+		return 0, fmt.Errorf("%w: %d / %d", ErrOverflow, x, y)
+	}
+	return x / y, nil
+}
+
 func ExampleAdd() {
 
 	sum := Add(1, 5)
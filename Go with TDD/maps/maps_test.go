@@ -1,6 +1,7 @@
 package maps
 
 import (
+	"gopractice/testutil"
 	"testing"
 )
 
@@ -11,7 +12,7 @@ func TestSearch(t *testing.T) {
 		got, _ := dictionary.Search("test")
 		want := "this is just a test carol"
 
-		assertStrings(t, got, want)
+		testutil.AssertEqual(t, got, want)
 	})
 
 	t.Run("unknown word", func(t *testing.T) {
@@ -21,7 +22,7 @@ func TestSearch(t *testing.T) {
 			t.Fatal("expected to get an error.")
 		}
 
-		assertError(t, err, ErrNotFound)
+		testutil.AssertError(t, err, ErrNotFound)
 	})
 }
 
@@ -33,8 +34,10 @@ func TestAdd(t *testing.T) {
 
 		err := dictionary.Add(word, definition)
 
-		assertError(t, err, nil)
-		assertDefinition(t, dictionary, word, definition)
+		testutil.AssertNoError(t, err)
+		got, err := dictionary.Search(word)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, got, definition)
 	})
 
 	t.Run("existing word", func(t *testing.T) {
@@ -43,8 +46,10 @@ func TestAdd(t *testing.T) {
 		dictionary := Dictionary{word: definition}
 		err := dictionary.Add(word, "new test")
 
-		assertError(t, err, ErrWordExists)
-		assertDefinition(t, dictionary, word, definition)
+		testutil.AssertError(t, err, ErrWordExists)
+		got, err := dictionary.Search(word)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, got, definition)
 	})
 }
 
@@ -57,8 +62,10 @@ func TestUpdate(t *testing.T) {
 
 		err := dictionary.Update(word, newDefinition)
 
-		assertError(t, err, nil)
-		assertDefinition(t, dictionary, word, newDefinition)
+		testutil.AssertNoError(t, err)
+		got, err := dictionary.Search(word)
+		testutil.AssertNoError(t, err)
+		testutil.AssertEqual(t, got, newDefinition)
 	})
 
 	t.Run("new word", func(t *testing.T) {
@@ -68,7 +75,7 @@ func TestUpdate(t *testing.T) {
 
 		err := dictionary.Update(word, definition)
 
-		assertError(t, err, ErrWordDoesNotExist)
+		testutil.AssertError(t, err, ErrWordDoesNotExist)
 	})
 }
 
@@ -79,11 +86,11 @@ func TestDelete(t *testing.T) {
 
 		err := dictionary.Delete(word)
 
-		assertError(t, err, nil)
+		testutil.AssertNoError(t, err)
 
 		_, err = dictionary.Search(word)
 
-		assertError(t, err, ErrNotFound)
+		testutil.AssertError(t, err, ErrNotFound)
 	})
 
 	t.Run("non-existing word", func(t *testing.T) {
@@ -92,32 +99,6 @@ func TestDelete(t *testing.T) {
 
 		err := dictionary.Delete(word)
 
-		assertError(t, err, ErrWordDoesNotExist)
+		testutil.AssertError(t, err, ErrWordDoesNotExist)
 	})
 }
-
-func assertStrings(t testing.TB, got, want string) {
-	t.Helper()
-
-	if got != want {
-		t.Errorf("got %q want %q", got, want)
-	}
-}
-
-func assertError(t testing.TB, got, want error) {
-	t.Helper()
-
-	if got != want {
-		t.Errorf("got error %q want %q", got, want)
-	}
-}
-
-func assertDefinition(t testing.TB, dictionary Dictionary, word, definition string) {
-	t.Helper()
-
-	got, err := dictionary.Search(word)
-	if err != nil {
-		t.Fatal("should find added word:", err)
-	}
-	assertStrings(t, got, definition)
-}
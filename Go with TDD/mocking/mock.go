@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -58,3 +59,34 @@ func Countdown(out io.Writer, sleeper Sleeper) {
 	}
 	fmt.Fprint(out, finalWord)
 }
+
+// ContextSleeper sleeps like DefaultSleeper but wakes up early once its context is done
+// it still just satisfies Sleeper, Sleep() has no way to return an error so the context is stored on the struct
+type ContextSleeper struct {
+	Ctx      context.Context
+	Duration time.Duration
+}
+
+func (s *ContextSleeper) Sleep() {
+	timer := time.NewTimer(s.Duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-s.Ctx.Done():
+	}
+}
+
+// CountdownContext is Countdown that gives up once ctx is done instead of finishing the count
+// the sleeper should watch the same ctx (ContextSleeper) or the abort only happens after the sleep
+func CountdownContext(ctx context.Context, out io.Writer, sleeper Sleeper) error {
+	for i := countdownStart; i > 0; i-- {
+		fmt.Fprintln(out, i)
+		sleeper.Sleep()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(out, finalWord)
+	return nil
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestCountdown(t *testing.T) {
@@ -42,3 +44,67 @@ Go!`
 		}
 	})
 }
+
+func TestContextSleeper(t *testing.T) {
+	t.Run("wakes up when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		sleeper := &ContextSleeper{Ctx: ctx, Duration: time.Minute}
+
+		start := time.Now()
+		sleeper.Sleep()
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("slept %v, should have returned right after the cancel", elapsed)
+		}
+	})
+
+	t.Run("sleeps the full duration otherwise", func(t *testing.T) {
+		sleeper := &ContextSleeper{Ctx: context.Background(), Duration: 5 * time.Millisecond}
+
+		start := time.Now()
+		sleeper.Sleep()
+
+		if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+			t.Errorf("slept %v want at least 5ms", elapsed)
+		}
+	})
+}
+
+func TestCountdownContext(t *testing.T) {
+	t.Run("aborts mid sleep", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		buffer := &bytes.Buffer{}
+		start := time.Now()
+		err := CountdownContext(ctx, buffer, &ContextSleeper{Ctx: ctx, Duration: time.Second})
+
+		if err != context.DeadlineExceeded {
+			t.Errorf("got error %v want %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("took %v, the countdown should not wait out the second", elapsed)
+		}
+		if got := buffer.String(); got != "3\n" {
+			t.Errorf("got %q want only the first number", got)
+		}
+	})
+
+	t.Run("counts all the way down", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := CountdownContext(context.Background(), buffer, &SpySleeper{})
+
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		want := `3
+2
+1
+Go!`
+		if got := buffer.String(); got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package pointers
 
 import (
+	"gopractice/testutil"
 	"testing"
 )
 
@@ -9,40 +10,20 @@ func TestWallet(t *testing.T) {
 	t.Run("deposit", func(t *testing.T) {
 		wallet := Wallet{}
 		wallet.Deposit(Bitcoin(10))
-		assertBalance(t, wallet, Bitcoin(10))
+		testutil.AssertEqual(t, wallet.Balance(), Bitcoin(10))
 	})
 
 	t.Run("withdraw with funds", func(t *testing.T) {
 		wallet := Wallet{Bitcoin(20)}
 		wallet.Withdraw(Bitcoin(10))
-		assertBalance(t, wallet, Bitcoin(10))
+		testutil.AssertEqual(t, wallet.Balance(), Bitcoin(10))
 	})
 
 	t.Run("withdraw insufficient funds", func(t *testing.T) {
 		wallet := Wallet{Bitcoin(20)}
 		err := wallet.Withdraw(Bitcoin(100))
 
-		assertError(t, err, ErrInsufficientFunds)
-		assertBalance(t, wallet, Bitcoin(20))
+		testutil.AssertError(t, err, ErrInsufficientFunds)
+		testutil.AssertEqual(t, wallet.Balance(), Bitcoin(20))
 	})
 }
-
-func assertBalance(t testing.TB, wallet Wallet, want Bitcoin) {
-	t.Helper()
-	got := wallet.Balance()
-
-	if got != want {
-		t.Errorf("got %q want %q", got, want)
-	}
-}
-
-func assertError(t testing.TB, got, want error) {
-	t.Helper()
-	if got == nil {
-		t.Fatal("didn't get an error but wanted one")
-	}
-
-	if got != want {
-		t.Errorf("got %q, want %q", got, want)
-	}
-}
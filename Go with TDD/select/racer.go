@@ -1,32 +1,153 @@
 package racer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 )
 
 func Racer(a, b string, timout time.Duration) (winner string, errror error) {
+	// once we return, cancel tells the losing ping to drop its request instead of hanging around
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	select {
-	case <-ping(a):
-		return a, nil
-	case <-ping(a):
+	case <-ping(ctx, a):
 		return a, nil
+	case <-ping(ctx, b):
+		return b, nil
 	case <-time.After(timout):
 		return "", fmt.Errorf("timed out waiting for %s and %s", a, b)
 
 	}
 }
-func measureResponseTime(url string) time.Duration {
+
+// FastestURL is the slow but deterministic cousin of Racer
+// it measures every url one after the other and returns the one with the lowest latency
+// urls that fail to respond are skipped, it only errors when none of them answered
+func FastestURL(urls ...string) (string, error) {
+	if len(urls) == 0 {
+		return "", fmt.Errorf("no urls to measure")
+	}
+
+	fastest := ""
+	var best time.Duration
+
+	for _, url := range urls {
+		duration, err := measureResponseTime(context.Background(), url)
+		if err != nil {
+			continue
+		}
+		if fastest == "" || duration < best {
+			fastest, best = url, duration
+		}
+	}
+
+	if fastest == "" {
+		return "", fmt.Errorf("none of the %d urls responded", len(urls))
+	}
+	return fastest, nil
+}
+
+// RaceEntry is how one url did in RaceDetailed
+type RaceEntry struct {
+	URL      string
+	Duration time.Duration
+	TimedOut bool
+	Err      error // set when the request failed for any other reason
+}
+
+// RaceResult is the full picture of a race, Ranking is fastest first with timeouts and failures at the end
+type RaceResult struct {
+	Winner   string
+	Duration time.Duration
+	Ranking  []RaceEntry
+}
+
+// RaceDetailed races all urls at once like Racer but waits for everyone (up to timeout)
+// and reports how long each one took, a mini benchmark instead of just a winner
+func RaceDetailed(urls []string, timeout time.Duration) (RaceResult, error) {
+	if len(urls) == 0 {
+		return RaceResult{}, fmt.Errorf("no urls to race")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// each goroutine only writes its own slot so no mutex is needed
+	entries := make([]RaceEntry, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			duration, err := measureResponseTime(ctx, url)
+			entries[i] = RaceEntry{URL: url, Duration: duration, Err: err}
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				entries[i] = RaceEntry{URL: url, Duration: timeout, TimedOut: true}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// finishers sorted by duration, then everyone who did not make it
+	sort.SliceStable(entries, func(i, j int) bool {
+		iFinished := entries[i].Err == nil && !entries[i].TimedOut
+		jFinished := entries[j].Err == nil && !entries[j].TimedOut
+		if iFinished != jFinished {
+			return iFinished
+		}
+		return entries[i].Duration < entries[j].Duration
+	})
+
+	result := RaceResult{Ranking: entries}
+	if first := entries[0]; first.Err == nil && !first.TimedOut {
+		result.Winner = first.URL
+		result.Duration = first.Duration
+		return result, nil
+	}
+	return result, fmt.Errorf("none of the %d urls responded within %v", len(urls), timeout)
+}
+
+func measureResponseTime(ctx context.Context, url string) (time.Duration, error) {
 	start := time.Now()
-	http.Get(url)
-	return time.Since(start)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return time.Since(start), nil
 }
-func ping(url string) chan struct{} {
+
+// ping signals on the channel when url answers with a 2xx
+// a failed request or an error status never signals, so Racer can't crown a url that is down
+// and only times out when none of them succeeded
+// the request is tied to ctx so a cancelled race aborts it, otherwise the goroutine leaks until the slow server replies
+func ping(ctx context.Context, url string) chan struct{} {
 	ch := make(chan struct{})
 	go func() {
-		http.Get(url)
-		close(ch)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			close(ch)
+		}
 	}()
 	return ch
 }
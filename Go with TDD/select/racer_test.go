@@ -7,6 +7,8 @@ import (
 	"time"
 )
 
+const tenSecondTimeout = 10 * time.Second
+
 func TestRacer(t *testing.T) {
 	t.Run("compares speeds of servers, returning the url of the fastest one", func(t *testing.T) {
 		slowServer := makeDelayedServer(20 * time.Millisecond)
@@ -19,13 +21,40 @@ func TestRacer(t *testing.T) {
 		fastURL := fastServer.URL
 
 		want := fastURL
-		got, _ := Racer(slowURL, fastURL)
+		got, _ := Racer(slowURL, fastURL, tenSecondTimeout)
 
 		if got != want {
 			t.Errorf("got %q, want %q", got, want)
 		}
 	})
 
+	t.Run("a fast failure does not win against a slow success", func(t *testing.T) {
+		brokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		slowServer := makeDelayedServer(20 * time.Millisecond)
+		defer brokenServer.Close()
+		defer slowServer.Close()
+
+		got, err := Racer(brokenServer.URL, slowServer.URL, tenSecondTimeout)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got %v", err)
+		}
+		if got != slowServer.URL {
+			t.Errorf("got %q, want %q", got, slowServer.URL)
+		}
+	})
+
+	t.Run("times out when every url fails", func(t *testing.T) {
+		deadServer := makeDelayedServer(0)
+		deadServer.Close()
+
+		_, err := Racer(deadServer.URL, deadServer.URL, 20*time.Millisecond)
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
 	t.Run("returns an error if a server doesn't respond within 10s", func(t *testing.T) {
 		serverA := makeDelayedServer(11 * time.Second)
 		serverB := makeDelayedServer(12 * time.Second)
@@ -33,13 +62,109 @@ func TestRacer(t *testing.T) {
 		defer serverA.Close()
 		defer serverB.Close()
 
-		_, err := Racer(serverA.URL, serverB.URL)
+		_, err := Racer(serverA.URL, serverB.URL, tenSecondTimeout)
 
 		if err == nil {
 			t.Error("expected an error but didn't get one")
 		}
 	})
 }
+
+func TestFastestURL(t *testing.T) {
+	t.Run("picks the url with the lowest measured latency", func(t *testing.T) {
+		slowServer := makeDelayedServer(20 * time.Millisecond)
+		fastServer := makeDelayedServer(0 * time.Millisecond)
+
+		defer slowServer.Close()
+		defer fastServer.Close()
+
+		got, err := FastestURL(slowServer.URL, fastServer.URL)
+		if err != nil {
+			t.Fatalf("didn't expect an error but got %v", err)
+		}
+
+		if got != fastServer.URL {
+			t.Errorf("got %q, want %q", got, fastServer.URL)
+		}
+	})
+
+	t.Run("returns an error when nothing responds", func(t *testing.T) {
+		deadServer := makeDelayedServer(0)
+		deadServer.Close()
+
+		_, err := FastestURL(deadServer.URL)
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+func TestRacerCancelsTheLoser(t *testing.T) {
+	aborted := make(chan struct{})
+
+	// the slow server only finishes when the client gives up on it
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	fastServer := makeDelayedServer(0 * time.Millisecond)
+
+	defer slowServer.Close()
+	defer fastServer.Close()
+
+	got, err := Racer(slowServer.URL, fastServer.URL, time.Second)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got %v", err)
+	}
+	if got != fastServer.URL {
+		t.Errorf("got %q, want %q", got, fastServer.URL)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("the losing request was never cancelled")
+	}
+}
+
+func TestRaceDetailed(t *testing.T) {
+	slowServer := makeDelayedServer(30 * time.Millisecond)
+	fastServer := makeDelayedServer(0 * time.Millisecond)
+	hangingServer := makeDelayedServer(300 * time.Millisecond)
+
+	defer slowServer.Close()
+	defer fastServer.Close()
+	defer hangingServer.Close()
+
+	result, err := RaceDetailed([]string{slowServer.URL, hangingServer.URL, fastServer.URL}, 150*time.Millisecond)
+	if err != nil {
+		t.Fatalf("didn't expect an error but got %v", err)
+	}
+
+	if result.Winner != fastServer.URL {
+		t.Errorf("got winner %q, want %q", result.Winner, fastServer.URL)
+	}
+
+	// the winner has the smallest recorded duration of everyone who finished
+	for _, entry := range result.Ranking {
+		if !entry.TimedOut && entry.Duration < result.Duration {
+			t.Errorf("%s took %v, less than the winner's %v", entry.URL, entry.Duration, result.Duration)
+		}
+	}
+
+	want := []string{fastServer.URL, slowServer.URL, hangingServer.URL}
+	for i, entry := range result.Ranking {
+		if entry.URL != want[i] {
+			t.Errorf("rank %d: got %q, want %q", i, entry.URL, want[i])
+		}
+	}
+	if !result.Ranking[2].TimedOut {
+		t.Error("expected the hanging server to be marked as timed out")
+	}
+}
+
 func makeDelayedServer(delay time.Duration) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(delay)
@@ -0,0 +1,33 @@
+package slice_arr
+
+// Map gives back a new slice with f applied to every element, same length and order
+// T and U can differ, so Map(words, len) turns []string into []int
+func Map[T, U any](s []T, f func(T) U) []U {
+	out := make([]U, 0, len(s))
+	for _, v := range s {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+// Filter keeps the elements pred says yes to, in their original order
+// the input slice is left alone, the result is always a new slice (empty, not nil, when nothing matches)
+func Filter[T any](s []T, pred func(T) bool) []T {
+	out := make([]T, 0)
+	for _, v := range s {
+		if pred(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Reduce folds the slice into a single value, starting from init and feeding each element to f in order
+// the accumulator type A doesn't have to match T, Sum is Reduce(numbers, 0, add) and an empty slice gives back init
+func Reduce[T, A any](s []T, init A, f func(A, T) A) A {
+	acc := init
+	for _, v := range s {
+		acc = f(acc, v)
+	}
+	return acc
+}
@@ -0,0 +1,89 @@
+package slice_arr
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	t.Run("squares", func(t *testing.T) {
+		got := Map([]int{1, 2, 3, 4}, func(n int) int { return n * n })
+		want := []int{1, 4, 9, 16}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("to another type", func(t *testing.T) {
+		got := Map([]string{"go", "tdd"}, strings.ToUpper)
+		want := []string{"GO", "TDD"}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	t.Run("evens", func(t *testing.T) {
+		numbers := []int{1, 2, 3, 4, 5, 6}
+		got := Filter(numbers, isEven)
+		want := []int{2, 4, 6}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+		if !slices.Equal(numbers, []int{1, 2, 3, 4, 5, 6}) {
+			t.Errorf("the input was changed to %v", numbers)
+		}
+	})
+
+	t.Run("nothing matches", func(t *testing.T) {
+		got := Filter([]int{1, 3}, isEven)
+
+		if got == nil || len(got) != 0 {
+			t.Errorf("got %#v want an empty slice", got)
+		}
+	})
+}
+
+func TestReduce(t *testing.T) {
+	t.Run("summation", func(t *testing.T) {
+		got := Reduce([]int{1, 2, 3, 4, 5}, 0, func(acc, n int) int { return acc + n })
+		want := 15
+
+		if got != want {
+			t.Errorf("got %d want %d", got, want)
+		}
+	})
+
+	t.Run("string concatenation", func(t *testing.T) {
+		got := Reduce([]string{"a", "b", "c"}, ">", func(acc, s string) string { return acc + s })
+		want := ">abc"
+
+		if got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+
+	t.Run("different accumulator type", func(t *testing.T) {
+		got := Reduce([]string{"go", "tdd"}, 0, func(acc int, s string) int { return acc + len(s) })
+		want := 5
+
+		if got != want {
+			t.Errorf("got %d want %d", got, want)
+		}
+	})
+
+	t.Run("empty slice gives init", func(t *testing.T) {
+		got := Reduce(nil, 42, func(acc, n int) int { return acc + n })
+
+		if got != 42 {
+			t.Errorf("got %d want 42", got)
+		}
+	})
+}
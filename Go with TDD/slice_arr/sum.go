@@ -1,13 +1,16 @@
 package slice_arr
 
 func Sum(numbers []int) int {
-	sum := 0
-	for _, number := range numbers {
-		sum += number
-	}
-	return sum
+	add := func(acc, number int) int { return acc + number }
+	return Reduce(numbers, 0, add)
 }
 
+// the plain loop Sum started out as, Reduce (in functional.go) is the same loop with the + pulled out
+// sum := 0
+// for _, number := range numbers {
+//	sum += number
+// }
+//
 // this is for old stuff basically the old way we used to write loops in go
 // for i := 0; i < 5; i++ {
 //		sum += numbers[i]
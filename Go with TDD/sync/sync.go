@@ -1,6 +1,15 @@
 package sync
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Incrementer is what all the counters here have in common, so tests and benchmarks can swap them around
+type Incrementer interface {
+	Inc()
+	Value() int
+}
 
 type Counter struct {
 	mu    sync.Mutex
@@ -14,9 +23,81 @@ func (c *Counter) Inc() {
 }
 
 func (c *Counter) Value() int {
-	return 0
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
 }
 
 func NewCounter() *Counter {
 	return &Counter{}
 }
+
+// RunConcurrent starts n goroutines all running fn and only returns once every one of them is done
+// the WaitGroup lives in here so tests (ours or yours) dont have to repeat the Add/Done/Wait dance
+// n <= 0 runs nothing, a negative Add would panic the WaitGroup
+func RunConcurrent(n int, fn func()) {
+	if n <= 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+
+	for range n {
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+// RWCounter is a Counter for read heavy workloads
+// any number of readers can hold the read lock at once, only Inc and Dec have to wait for everyone
+type RWCounter struct {
+	mu    sync.RWMutex
+	value int
+}
+
+func (c *RWCounter) Inc() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value++
+}
+
+func (c *RWCounter) Dec() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value--
+}
+
+func (c *RWCounter) Value() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.value
+}
+
+// AtomicCounter does the same job without any lock at all
+// atomic.Int64 makes the cpu do the read-add-write as one indivisible step
+type AtomicCounter struct {
+	value atomic.Int64
+}
+
+func (c *AtomicCounter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *AtomicCounter) Dec() {
+	c.value.Add(-1)
+}
+
+func (c *AtomicCounter) Value() int {
+	return int(c.value.Load())
+}
+
+// compile time check that every counter really is an Incrementer
+var (
+	_ Incrementer = (*Counter)(nil)
+	_ Incrementer = (*RWCounter)(nil)
+	_ Incrementer = (*AtomicCounter)(nil)
+)
@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"gopractice/testutil"
 	"sync"
 	"testing"
 )
@@ -27,13 +28,116 @@ func TestCounter(t *testing.T) {
 
 		assertCounter(t, &counter, wantedCount)
 	})
+
+	t.Run("RunConcurrent drives the increments for us", func(t *testing.T) {
+		wantedCount := 1000
+		counter := NewCounter()
+
+		RunConcurrent(wantedCount, counter.Inc)
+
+		assertCounter(t, counter, wantedCount)
+	})
+
+	t.Run("RunConcurrent with n <= 0 runs nothing", func(t *testing.T) {
+		counter := NewCounter()
+
+		RunConcurrent(0, counter.Inc)
+		RunConcurrent(-1, counter.Inc)
+
+		assertCounter(t, counter, 0)
+	})
 }
 
+// the old hand written version printed a hardcoded 3 as the wanted value, the shared helper can't get that wrong
 func assertCounter(t testing.TB, got *Counter, want int) {
 	t.Helper()
+	testutil.AssertEqual(t, got.Value(), want)
+}
+
+func TestRWCounter(t *testing.T) {
+	t.Run("concurrent incs, decs and reads are safe", func(t *testing.T) {
+		counter := &RWCounter{}
+
+		// 1000 incs, 400 decs and a pile of readers all at once, go test -race catches any slip
+		RunConcurrent(1000, counter.Inc)
+		RunConcurrent(400, func() {
+			counter.Dec()
+			counter.Value()
+		})
+
+		if got := counter.Value(); got != 600 {
+			t.Errorf("got %d, want %d", got, 600)
+		}
+	})
+}
+
+// read heavy mix: one write for every 100 reads, run with go test -bench=Read
+func BenchmarkCounterRead(b *testing.B) {
+	counter := NewCounter()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%100 == 0 {
+				counter.Inc()
+			} else {
+				counter.Value()
+			}
+			i++
+		}
+	})
+}
 
-	if got.Value() != want {
-		t.Errorf("got %d, want %d", got.Value(), 3)
-	}
+func BenchmarkRWCounterRead(b *testing.B) {
+	counter := &RWCounter{}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%100 == 0 {
+				counter.Inc()
+			} else {
+				counter.Value()
+			}
+			i++
+		}
+	})
+}
+
+func TestAtomicCounter(t *testing.T) {
+	t.Run("10k concurrent increments", func(t *testing.T) {
+		counter := &AtomicCounter{}
+
+		RunConcurrent(10000, counter.Inc)
+
+		if got := counter.Value(); got != 10000 {
+			t.Errorf("got %d, want %d", got, 10000)
+		}
+	})
+
+	t.Run("dec goes back down", func(t *testing.T) {
+		counter := &AtomicCounter{}
+
+		RunConcurrent(10, counter.Inc)
+		RunConcurrent(4, counter.Dec)
+
+		if got := counter.Value(); got != 6 {
+			t.Errorf("got %d, want %d", got, 6)
+		}
+	})
+}
+
+// every goroutine hammering Inc at once, this is where the lock free version should pull ahead
+func benchmarkContention(b *testing.B, counter Incrementer) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Inc()
+		}
+	})
+}
+
+func BenchmarkCounterContention(b *testing.B) {
+	benchmarkContention(b, NewCounter())
+}
 
+func BenchmarkAtomicCounterContention(b *testing.B) {
+	benchmarkContention(b, &AtomicCounter{})
 }
@@ -0,0 +1,43 @@
+// Package testutil holds the assertion helpers the exercises kept rewriting in every test file
+package testutil
+
+import (
+	"errors"
+	"testing"
+)
+
+// AssertEqual fails the test (but keeps it running) when got and want differ
+// t.Helper makes the failure point at the caller's line, not at this file
+func AssertEqual[T comparable](t testing.TB, got, want T) {
+	t.Helper()
+
+	if got != want {
+		// strings are quoted so a missing space or an empty string shows up in the message
+		if s, ok := any(got).(string); ok {
+			t.Errorf("got %q want %q", s, any(want).(string))
+			return
+		}
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+// AssertNoError stops the test right away on an error, whatever comes after usually depends on it
+func AssertNoError(t testing.TB, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("didn't expect an error but got %v", err)
+	}
+}
+
+// AssertError checks got is (or wraps) want, no error at all stops the test
+func AssertError(t testing.TB, got, want error) {
+	t.Helper()
+
+	if got == nil {
+		t.Fatalf("didn't get an error but wanted %q", want)
+	}
+	if !errors.Is(got, want) {
+		t.Errorf("got error %q want %q", got, want)
+	}
+}
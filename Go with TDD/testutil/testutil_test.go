@@ -0,0 +1,111 @@
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakeTB records failures instead of failing the real test
+// embedding testing.TB fills in the methods we don't care about (and the unexported one we can't write)
+type fakeTB struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) Fatalf(format string, args ...any) {
+	f.Errorf(format, args...)
+	f.fatal = true
+}
+
+func TestAssertEqual(t *testing.T) {
+	t.Run("equal values pass", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertEqual(fake, "Hello", "Hello")
+		AssertEqual(fake, 1000, 1000)
+
+		if len(fake.errors) != 0 {
+			t.Errorf("got failures %v want none", fake.errors)
+		}
+	})
+
+	t.Run("different values fail with both in the message", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertEqual(fake, 999, 1000)
+
+		if len(fake.errors) != 1 || fake.errors[0] != "got 999 want 1000" {
+			t.Errorf("got failures %q want one saying got 999 want 1000", fake.errors)
+		}
+		if fake.fatal {
+			t.Error("AssertEqual should not stop the test")
+		}
+	})
+
+	t.Run("strings are quoted", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertEqual(fake, "Hey Carol", "HeyCarol")
+
+		if len(fake.errors) != 1 || fake.errors[0] != `got "Hey Carol" want "HeyCarol"` {
+			t.Errorf("got failures %q want the strings quoted", fake.errors)
+		}
+	})
+}
+
+func TestAssertNoError(t *testing.T) {
+	t.Run("nil passes", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertNoError(fake, nil)
+
+		if len(fake.errors) != 0 {
+			t.Errorf("got failures %v want none", fake.errors)
+		}
+	})
+
+	t.Run("an error stops the test", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertNoError(fake, errors.New("boom"))
+
+		if !fake.fatal {
+			t.Error("expected a fatal failure")
+		}
+	})
+}
+
+func TestAssertError(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run("the wanted error passes, wrapped too", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertError(fake, errBoom, errBoom)
+		AssertError(fake, fmt.Errorf("doing things: %w", errBoom), errBoom)
+
+		if len(fake.errors) != 0 {
+			t.Errorf("got failures %v want none", fake.errors)
+		}
+	})
+
+	t.Run("a different error fails", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertError(fake, errors.New("bang"), errBoom)
+
+		if len(fake.errors) != 1 || fake.fatal {
+			t.Errorf("got failures %q fatal %v want one non fatal failure", fake.errors, fake.fatal)
+		}
+	})
+
+	t.Run("no error stops the test", func(t *testing.T) {
+		fake := &fakeTB{}
+		AssertError(fake, nil, errBoom)
+
+		if !fake.fatal {
+			t.Error("expected a fatal failure")
+		}
+	})
+}
@@ -5,10 +5,21 @@ import (
 	"fmt"
 )
 
+// the tags rename the keys to lowercase, without them json uses the Go field names as is
 type Message struct {
-	Name string
-	Body string
-	Time int64
+	Name string `json:"name"`
+	Body string `json:"body"`
+	Time int64  `json:"time"`
+}
+
+// ParseMessage is the other half of json.Marshal, bytes back into a Message
+func ParseMessage(b []byte) (Message, error) {
+	var m Message
+	// Unmarshal needs a pointer so it can fill the struct in place
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Message{}, fmt.Errorf("unable to parse message: %w", err)
+	}
+	return m, nil
 }
 
 func main() {
@@ -19,4 +30,8 @@ func main() {
 	// Using %s to print bytes as string, %v for raw bytes
 	fmt.Printf("Raw bytes: %v\nError: %v\n", b, err)
 	fmt.Println("JSON String:", string(b))
+
+	// and back again, the round trip should give us the exact same struct
+	back, err := ParseMessage(b)
+	fmt.Printf("Parsed back: %+v\nError: %v\nSame as before: %v\n", back, err, back == m)
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	want := Message{Name: "Alice", Body: "Hello", Time: 1294706395881547000}
+
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	// the tags should give lowercase keys
+	if string(b) != `{"name":"Alice","body":"Hello","time":1294706395881547000}` {
+		t.Errorf("got %s", b)
+	}
+
+	got, err := ParseMessage(b)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		if _, err := ParseMessage([]byte(`{"name":`)); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
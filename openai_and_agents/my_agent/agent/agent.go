@@ -2,8 +2,14 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"my_agent/llm"
+	"my_agent/tools"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 // the main agent file that sees and takes care of all the things for us
@@ -13,13 +19,104 @@ type Agent struct {
 	//import from our openrouter client
 	client *llm.Client
 
+	// runMu is held by a Run from start to finish (and by the methods changing History)
+	// so two goroutines sharing an agent take turns instead of interleaving their messages
+	runMu sync.Mutex
+	// mu guards History and the run state below, a Run lets go of it around everything that calls out:
+	// the model request, tools, events, transforms, the router and the fingerprint callback
+	// so Transcript, PendingToolCalls, LastLogprobs and TotalCost work from inside those too
+	// the exported fields themselves are not guarded, go through the methods once runs are concurrent
+	mu sync.Mutex
+
 	// some of our new structs here
 	SystemPrompt string
 	MaxRetries   int
 	Model        string
 
+	// tools the model is allowed to call, nil means a plain chat agent
+	Tools *tools.Registry
+	// how many model round trips one Run may take before we give up on a final answer
+	MaxSteps int
+	// Deps is put into the context of every tool call, tools read it back with tools.FromContext
+	Deps any
+	// biggest user message Run accepts, 0 means no limit
+	MaxInputBytes int
+	// how many non-system messages are kept in History, 0 means all of them
+	MaxHistoryMessages int
+	// rough token limit for History, 0 means none unless ModelContextBudget is on
+	MaxHistoryTokens int
+	// derive the token limit from the model's context length, see WithModelContextBudget
+	ModelContextBudget bool
+	// tool outputs longer than this are cut before they go into history, 0 means no limit
+	MaxToolResultBytes int
+	// every Run starts from just the system prompt and leaves History as it was, see WithStateless
+	Stateless bool
+	// above 1 the tool calls of one turn run in parallel, at most this many at once
+	MaxConcurrentToolCalls int
+	// retries one Run may spend on api calls and tools together, 0 means no shared limit, see WithRetryBudget
+	RetryBudget int
+	// keep the text between tool calls out of the events, see WithFinalOnly
+	FinalOnly bool
+	// ask again when the final answer is empty, see WithRetryOnEmpty
+	RetryOnEmpty bool
+	// describe the tools in the prompt for models without function calling instead of failing, see WithToolFallback
+	ToolFallback bool
+	// OpenRouter prompt transforms sent with every request, see WithTransforms
+	Transforms []string
+
+	// sampling parameters set explicitly on this agent, see CallOptions for how they combine
+	Options CallOptions
+	// per model defaults, used for whatever the agent did not set itself
+	ModelDefaults map[string]CallOptions
+	// temperature per Run, see WithTemperatureSchedule
+	TemperatureSchedule func(turn int) float64
+	// model per Run, see WithModelRouter
+	ModelRouter func(msg string, history []llm.Message) string
+
 	// state in the agent something that keeps on passing with each loop
+	// with WithRingBuffer the slice is a view into the ring, a copy taken earlier (snap := a.History)
+	// is overwritten by later appends, use slices.Clone to keep one
 	History []llm.Message
+	// fixed size storage behind History, nil unless WithRingBuffer
+	ring *historyRing
+
+	// how many runs got as far as calling the model, the turn TemperatureSchedule sees
+	turns int
+	// what ModelRouter picked for the current run, empty means Model
+	runModel string
+
+	// tokens spent so far per model, TotalCost prices them
+	usage map[string]llm.Usage
+
+	// tools the model may call but we never run, see WithManualTools
+	manualTools map[string]bool
+	// manual tool calls waiting for ResolveToolCall
+	pending []llm.ToolCall
+
+	// tools offered for the current RunWithTools, nil means all of them
+	allowedTools map[string]bool
+
+	// phrasing of failed tool calls, nil means llm.NewToolError, see WithToolErrorFormatter
+	toolErrorFormat func(toolName string, err error) string
+
+	// rewrite the user message and the final answer of Run, see WithInputTransform and WithOutputTransform
+	inputTransforms  []func(string) string
+	outputTransforms []func(string) string
+
+	// the steps of the last run, see LastTraceJSON
+	trace []TraceStep
+
+	// logprobs of the last final answer, only set with WithLogprobs
+	lastLogprobs *llm.Logprobs
+
+	// the previous successful run and who to tell when its backend changed, see WithFingerprintWarning
+	lastResult          *RunResult
+	onFingerprintChange func(previous, current string)
+
+	// optional persistence, see WithStore
+	store     HistoryStore
+	sessionID string
+	loaded    bool
 }
 
 type Option func(*Agent)
@@ -31,7 +128,9 @@ func New(client *llm.Client, model string, opts ...Option) *Agent {
 		client:     client,
 		Model:      model,
 		MaxRetries: 1,
+		MaxSteps:   10,
 		History:    make([]llm.Message, 0),
+		usage:      make(map[string]llm.Usage),
 	}
 
 	// Apply Options
@@ -41,13 +140,40 @@ func New(client *llm.Client, model string, opts ...Option) *Agent {
 	}
 
 	// Init History with System Prompt if present
-	if a.SystemPrompt != "" {
-		a.History = append(a.History, llm.NewSystemMessage(a.SystemPrompt))
+	// a seeded history that already opens with a system message wins, we never want two of them
+	if len(a.History) > 0 && a.History[0].IsSystem() {
+		a.SystemPrompt = a.History[0].Content
+	} else if a.SystemPrompt != "" {
+		a.History = append([]llm.Message{llm.NewSystemMessage(a.SystemPrompt)}, a.History...)
 	}
 
 	return a
 }
 
+// SetSystemPrompt swaps the system prompt mid session, the next request already uses the new one
+// the rest of the history is left as it is, an empty prompt removes the system message
+// there is only ever one system message afterwards, any stray extra ones are dropped
+func (a *Agent) SetSystemPrompt(prompt string) {
+	a.lockRun()
+	defer a.unlockRun()
+	a.setSystemPrompt(prompt)
+}
+
+func (a *Agent) setSystemPrompt(prompt string) {
+	a.SystemPrompt = prompt
+
+	history := make([]llm.Message, 0, len(a.History)+1)
+	if prompt != "" {
+		history = append(history, llm.NewSystemMessage(prompt))
+	}
+	for _, msg := range a.History {
+		if !msg.IsSystem() {
+			history = append(history, msg)
+		}
+	}
+	a.History = history
+}
+
 // now defining our opt functions
 // returns kinda like a nested fun where we return the Option variable but inside it only the system prompt that is what we care about when triggering this function
 func WithSystemPrompts(prompt string) Option {
@@ -56,6 +182,15 @@ func WithSystemPrompts(prompt string) Option {
 	}
 }
 
+// WithRetryBudget caps the retries of a whole Run, api calls and tool calls combined
+// each still has its own limit (Client.MaxRetries, the tool's RetryPolicy), this is the ceiling over all of them
+// once it is spent the failing call returns an error wrapping llm.ErrRetryBudgetExhausted
+func WithRetryBudget(total int) Option {
+	return func(a *Agent) {
+		a.RetryBudget = total
+	}
+}
+
 // same here Option holds the agent but this function will trigger the max retries part only for us which is a lot cleaner
 func WithMaxRetries(n int) Option {
 	return func(a *Agent) {
@@ -63,39 +198,451 @@ func WithMaxRetries(n int) Option {
 	}
 }
 
+// ErrEmptyResponse is what Run returns with WithRetryOnEmpty when every retry still came back empty
+var ErrEmptyResponse = errors.New("model returned an empty response")
+
+// WithRetryOnEmpty asks again, up to MaxRetries times, when the model ends its turn with no text at all
+// (blank or only whitespace, tool calls don't count), some providers do that under load
+// once the retries are spent Run fails with ErrEmptyResponse instead of returning ""
+// every retry is a model round trip, so it counts towards MaxSteps
+func WithRetryOnEmpty() Option {
+	return func(a *Agent) {
+		a.RetryOnEmpty = true
+	}
+}
+
+// seeds the history, for rebuilding an agent from a saved session in one go
+// if msgs already starts with a system message it is kept and WithSystemPrompts is ignored
+func WithInitialHistory(msgs []llm.Message) Option {
+	return func(a *Agent) {
+		a.History = append([]llm.Message(nil), msgs...)
+	}
+}
+
+// the toolbox the model can reach for, every registered tool is sent with each request
+func WithTools(registry *tools.Registry) Option {
+	return func(a *Agent) {
+		a.Tools = registry
+	}
+}
+
+// WithToolErrorFormatter changes the message a failed tool call puts in front of the model
+// the default is llm.NewToolError's "Error executing tool: ... Please fix your arguments."
+// some models react better to other wording, or need the tool name spelled out
+func WithToolErrorFormatter(format func(toolName string, err error) string) Option {
+	return func(a *Agent) {
+		a.toolErrorFormat = format
+	}
+}
+
+// WithMaxToolResultBytes cuts tool outputs over n bytes, a tool dumping a whole file would eat the context window
+func WithMaxToolResultBytes(n int) Option {
+	return func(a *Agent) {
+		a.MaxToolResultBytes = n
+	}
+}
+
+// WithMaxConcurrentToolCalls runs the tool calls of one model turn in parallel, at most n at a time
+// the rest wait for a free slot, so a model asking for 20 lookups at once can't flood a rate limited api
+// results still go into history in the order the model asked for them, n <= 1 keeps them sequential
+func WithMaxConcurrentToolCalls(n int) Option {
+	return func(a *Agent) {
+		a.MaxConcurrentToolCalls = n
+	}
+}
+
+// caps the model -> tools -> model loop so a confused model cant spin forever
+func WithMaxSteps(n int) Option {
+	return func(a *Agent) {
+		a.MaxSteps = n
+	}
+}
+
+// dependencies for the tools (db handle, current user ...) that have no business being in the json arguments
+func WithDeps(deps any) Option {
+	return func(a *Agent) {
+		a.Deps = deps
+	}
+}
+
 func (a *Agent) Run(ctx context.Context, usrMsg string) (string, error) {
+	result, err := a.RunResult(ctx, usrMsg)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// runSession wraps the loop with loading and saving the session, emit (can be nil) gets the step events
+func (a *Agent) runSession(ctx context.Context, usrMsg string, emit func(AgentEvent)) (*RunResult, error) {
+	a.lockRun()
+	defer a.unlockRun()
+	return a.runSessionLocked(ctx, usrMsg, emit)
+}
+
+// lockRun waits for a run in progress and takes both locks, unlockRun lets go of them
+// for runs and for the methods that change History, the read only ones take just a.mu
+func (a *Agent) lockRun() {
+	a.runMu.Lock()
+	a.mu.Lock()
+}
 
-	if usrMsg != "" {
+func (a *Agent) unlockRun() {
+	a.mu.Unlock()
+	a.runMu.Unlock()
+}
+
+// unlocked runs f with a.mu let go, for whatever a run calls out to
+// the run still holds runMu so only readers get in meanwhile, nothing changes under it
+func (a *Agent) unlocked(f func()) {
+	a.mu.Unlock()
+	defer a.mu.Lock()
+	f()
+}
+
+// runSessionLocked is runSession for callers already holding both locks
+func (a *Agent) runSessionLocked(ctx context.Context, usrMsg string, emit func(AgentEvent)) (*RunResult, error) {
+	if err := a.loadSession(); err != nil {
+		return nil, err
+	}
+
+	result, err := a.run(ctx, usrMsg, emit)
+
+	// persist even a failed run, the user message is already part of the history
+	if saveErr := a.saveSession(); saveErr != nil && err == nil {
+		return nil, saveErr
+	}
+	return result, err
+}
+
+func (a *Agent) run(ctx context.Context, usrMsg string, emit func(AgentEvent)) (*RunResult, error) {
+	if emit == nil {
+		emit = func(AgentEvent) {}
+	}
+	// the consumer may well call back into the agent, so events are sent without a.mu
+	send := emit
+	emit = func(event AgentEvent) {
+		a.unlocked(func() { send(event) })
+	}
+
+	if a.Stateless {
+		defer a.statelessHistory()()
+	}
+
+	if strings.TrimSpace(usrMsg) != "" {
+		a.unlocked(func() { usrMsg = applyTransforms(usrMsg, a.inputTransforms) })
+	}
+	if err := a.checkInput(usrMsg); err != nil {
+		return nil, err
+	}
+
+	// the history ends in tool calls without results, nothing can be sent until they are answered
+	if len(a.pending) > 0 {
+		return nil, fmt.Errorf("%w: %d tool calls still need ResolveToolCall", ErrWaitingForTools, len(a.pending))
+	}
+
+	a.routeModel(usrMsg)
+	defer func() { a.runModel = "" }()
+
+	if strings.TrimSpace(usrMsg) != "" {
 
 		userMessage := llm.NewUserMessage(usrMsg)
-		a.History = append(a.History, userMessage)
+		a.appendHistory(userMessage)
+
+	}
+	a.trimHistory()
+	a.trimHistoryTokens()
+	defer func() { a.turns++ }()
+
+	// one budget for the whole run, the client and the registry both take their retries from it
+	if a.RetryBudget > 0 {
+		ctx = llm.WithRetryBudget(ctx, llm.NewRetryBudget(a.RetryBudget))
+	}
 
+	// every tool call below gets this context so the tools can pull their deps out of it
+	if a.Deps != nil {
+		ctx = tools.WithDeps(ctx, a.Deps)
 	}
-	// prepare the request
+
+	result := &RunResult{}
+	a.trace = nil
+	emptyRetries := 0
+
+	// the loop: ask the model, if it wants tools run them and feed the results back, repeat till it answers
+	for step := 0; step < a.MaxSteps; step++ {
+		// prepare the request
+		req := a.buildRequest()
+		if err := a.gateTools(&req); err != nil {
+			return nil, err
+		}
+
+		var resp *llm.ChatResponse
+		var err error
+		a.unlocked(func() { resp, err = a.client.CreateChat(ctx, req) })
+		a.traceCall(req, resp, err)
+		// basic err handling
+		if err != nil {
+			// say which phase ran out of time, errors.Is(err, context.DeadlineExceeded) still works
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, fmt.Errorf("LLM call interrupted: %w", ctxErr)
+			}
+			return nil, fmt.Errorf("LLM call failed: %w", err)
+		}
+		a.usage[req.Model] = a.usage[req.Model].Add(resp.Usage)
+		result.Usage = result.Usage.Add(resp.Usage)
+		result.Model = resp.Model
+		if result.Model == "" {
+			result.Model = req.Model
+		}
+		// also check for resp.choices just to make sure
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("No other choice given")
+
+		}
+
+		msg := resp.Choices[0].Message
+
+		if !msg.IsToolCall() {
+			// nothing goes into history for an empty answer, the retry sends the same request again
+			if a.RetryOnEmpty && strings.TrimSpace(msg.Content) == "" {
+				if emptyRetries >= a.MaxRetries {
+					return nil, fmt.Errorf("%w after %d retries", ErrEmptyResponse, emptyRetries)
+				}
+				emptyRetries++
+				continue
+			}
+
+			a.lastLogprobs = resp.Choices[0].Logprobs
+
+			// extract the output and put it in var
+			var assistantContent string
+			a.unlocked(func() { assistantContent = applyTransforms(msg.Content, a.outputTransforms) })
+
+			assistantMessage := llm.NewAssistantMessage(assistantContent)
+			// obviously update the history
+			a.appendHistory(assistantMessage)
+			emit(AgentEvent{Type: EventFinal, Content: assistantContent})
+			// return the thing assistant spat out or just nil
+			result.Content = assistantContent
+			result.FinishReason = resp.Choices[0].FinishReason
+			result.Seed = req.Seed
+			result.SystemFingerprint = resp.SystemFingerprint
+			a.checkFingerprint(result)
+			return result, nil
+		}
+
+		// the assistant turn asking for tools has to be in history before the results that answer it
+		toolCallMessage := llm.NewToolCallMessage(msg.ToolCalls)
+		toolCallMessage.Content = msg.Content
+		a.appendHistory(toolCallMessage)
+		result.ToolCalls = append(result.ToolCalls, msg.ToolCalls...)
+		if msg.Content != "" && !a.FinalOnly {
+			emit(AgentEvent{Type: EventAssistantText, Content: msg.Content})
+		}
+
+		auto, manual := a.splitManual(msg.ToolCalls)
+		// send, not emit, runToolCalls runs without a.mu already
+		var toolResults []llm.Message
+		a.unlocked(func() { toolResults = a.runToolCalls(ctx, auto, send) })
+		a.traceToolResults(auto, toolResults)
+		a.appendHistory(toolResults...)
+		if len(manual) > 0 {
+			a.pending = manual
+			return nil, ErrWaitingForTools
+		}
+
+		// every call got a result (the deadline message for the ones cut short) so the history stays valid
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("tool calls interrupted: %w", ctxErr)
+		}
+	}
+
+	return nil, fmt.Errorf("no final answer after %d steps", a.MaxSteps)
+
+}
+
+// buildRequest snapshots the current history and settings into the request for the next model call
+func (a *Agent) buildRequest() llm.ChatRequest {
 	req := llm.ChatRequest{
 
-		Model:       a.Model,
-		Messages:    a.History,
-		Temperature: 0.7, // for now its hardcoded
+		Model:      a.model(),
+		Messages:   a.History,
+		Transforms: a.Transforms,
 	}
+	if a.Tools != nil {
+		req.Tools = scopeTools(a.Tools.Definitions(), a.allowedTools)
+	}
+	a.callOptions(req.Model).apply(&req)
+	return req
+}
+
+// runToolCalls runs the calls of one assistant turn and returns their results in call order
+// one after the other by default, or up to MaxConcurrentToolCalls at a time
+func (a *Agent) runToolCalls(ctx context.Context, calls []llm.ToolCall, emit func(AgentEvent)) []llm.Message {
+	results := make([]llm.Message, len(calls))
+
+	if a.MaxConcurrentToolCalls <= 1 || len(calls) == 1 {
+		for i, call := range calls {
+			emit(AgentEvent{Type: EventToolCallStarted, ToolCall: &call})
+
+			result, err := a.callTool(ctx, call)
+			results[i] = result
+
+			emit(AgentEvent{Type: EventToolCallFinished, ToolCall: &call, Content: result.Content, Err: err})
+		}
+		return results
+	}
+
+	// emit is not expected to be safe for concurrent use, so events go out one at a time
+	var emitMu sync.Mutex
+	safeEmit := func(event AgentEvent) {
+		emitMu.Lock()
+		defer emitMu.Unlock()
+		emit(event)
+	}
+
+	// a buffered channel as a semaphore, the calls past the limit queue up on it
+	slots := make(chan struct{}, a.MaxConcurrentToolCalls)
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			safeEmit(AgentEvent{Type: EventToolCallStarted, ToolCall: &call})
 
-	resp, err := a.client.CreateChat(ctx, req)
-	// basic err handling
+			result, err := a.callTool(ctx, call)
+			results[i] = result
+
+			safeEmit(AgentEvent{Type: EventToolCallFinished, ToolCall: &call, Content: result.Content, Err: err})
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// callTool runs one tool call, a failing tool becomes a tool error message so the model can fix its arguments
+// the error is handed back as well so callers can report it, the message already carries it for the model
+func (a *Agent) callTool(ctx context.Context, call llm.ToolCall) (llm.Message, error) {
+	if a.Tools == nil {
+		err := fmt.Errorf("no tools are available")
+		return a.toolError(call, err), err
+	}
+
+	// a model can still name a tool it wasn't offered, RunWithTools means it
+	if a.allowedTools != nil && !a.allowedTools[call.Function.Name] {
+		err := fmt.Errorf("tool %q is not available right now", call.Function.Name)
+		return a.toolError(call, err), err
+	}
+
+	// no point starting a tool the context already gave up on
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return interruptedToolResult(call, ctxErr), ctxErr
+	}
+
+	output, err := a.Tools.Call(ctx, call.Function.Name, call.Function.Arguments)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return interruptedToolResult(call, ctxErr), err
+	}
+	if err != nil {
+		return a.toolError(call, err), err
+	}
+	return llm.NewToolResult(call.ID, a.truncateToolResult(output)), nil
+}
+
+// interruptedToolResult tells the model the tool was cut off by the deadline, not broken
+// the usual tool error asks it to fix its arguments which is the wrong advice here
+func interruptedToolResult(call llm.ToolCall, ctxErr error) llm.Message {
+	reason := "the request was cancelled"
+	if errors.Is(ctxErr, context.DeadlineExceeded) {
+		reason = "the time for this request ran out"
+	}
+	return llm.NewToolResult(call.ID, fmt.Sprintf(
+		"Error executing tool %s: %s (%v) before it finished. Do not retry it, wrap up with what you already have.",
+		call.Function.Name, reason, ctxErr))
+}
+
+// toolError is the tool result telling the model its call failed
+func (a *Agent) toolError(call llm.ToolCall, err error) llm.Message {
+	if a.toolErrorFormat == nil {
+		return llm.NewToolError(call.ID, err)
+	}
+	return llm.NewToolResult(call.ID, a.toolErrorFormat(call.Function.Name, err))
+}
+
+// the marker tells the model it is not seeing everything
+const truncatedMarker = "...[truncated]"
+
+// truncateToolResult cuts output down to MaxToolResultBytes (marker included) without splitting a utf8 character
+// a limit smaller than the marker leaves the marker out, the limit wins
+func (a *Agent) truncateToolResult(output string) string {
+	if a.MaxToolResultBytes <= 0 || len(output) <= a.MaxToolResultBytes {
+		return output
+	}
+
+	marker := truncatedMarker
+	if a.MaxToolResultBytes < len(marker) {
+		marker = ""
+	}
+	cut := a.MaxToolResultBytes - len(marker)
+	for cut > 0 && !utf8.RuneStart(output[cut]) {
+		cut--
+	}
+	return output[:cut] + marker
+}
+
+// loadSession swaps in the stored history the first time the agent runs
+// a session that was never saved keeps the fresh history (with the system prompt) from New
+func (a *Agent) loadSession() error {
+	if a.store == nil || a.loaded {
+		return nil
+	}
+
+	msgs, err := a.store.Load(a.sessionID)
 	if err != nil {
-		return "", fmt.Errorf("LLM call failed: %w", err)
+		return fmt.Errorf("unable to load session %q: %w", a.sessionID, err)
+	}
+	if len(msgs) > 0 {
+		a.History = msgs
 	}
-	// also check for resp.choices just to make sure
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("No other choice given")
+	a.loaded = true
+	return nil
+}
 
+func (a *Agent) saveSession() error {
+	if a.store == nil {
+		return nil
+	}
+	if err := a.store.Save(a.sessionID, a.History); err != nil {
+		return fmt.Errorf("unable to save session %q: %w", a.sessionID, err)
 	}
-	// extract the output and put it in var
-	assistantContent := resp.Choices[0].Message.Content
+	return nil
+}
+
+// LastLogprobs is the token level confidence of the last final answer, nil unless WithLogprobs is on
+func (a *Agent) LastLogprobs() *llm.Logprobs {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastLogprobs
+}
 
-	assistantMessage := llm.NewAssistantMessage(assistantContent)
-	// obviously update the history
-	a.History = append(a.History, assistantMessage)
-	// return the thing assistant spat out or just nil
-	return assistantContent, nil
+// TotalCost prices every token this agent has used so far with the client's model pricing
+// the pricing may have to be fetched from /models, that happens on a copy without holding a.mu
+func (a *Agent) TotalCost() (float64, error) {
+	a.mu.Lock()
+	usages := maps.Clone(a.usage)
+	a.mu.Unlock()
 
+	var total float64
+	for model, usage := range usages {
+		cost, err := a.client.EstimateCost(model, usage)
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+	}
+	return total, nil
 }
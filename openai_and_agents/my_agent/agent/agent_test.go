@@ -0,0 +1,555 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"my_agent/llm"
+	"my_agent/tools"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// mockAPI plays back canned responses in order and keeps every request it got
+type mockAPI struct {
+	mu        sync.Mutex
+	responses []llm.ChatResponse
+	requests  []llm.ChatRequest
+}
+
+func (m *mockAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req llm.ChatRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, req)
+	if len(m.responses) == 0 {
+		http.Error(w, "no more canned responses", http.StatusBadRequest)
+		return
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// newMockClient starts a fake OpenRouter and returns a client pointed at it
+func newMockClient(t *testing.T, responses ...llm.ChatResponse) (*llm.Client, *mockAPI) {
+	t.Helper()
+
+	api := &mockAPI{responses: responses}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	client, err := llm.NewClient("test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+	return client, api
+}
+
+func textResponse(content string) llm.ChatResponse {
+	return llm.ChatResponse{
+		Choices: []llm.Choice{{Message: llm.NewAssistantMessage(content), FinishReason: "stop"}},
+	}
+}
+
+func toolCallResponse(calls ...llm.ToolCall) llm.ChatResponse {
+	return llm.ChatResponse{
+		Choices: []llm.Choice{{Message: llm.NewToolCallMessage(calls), FinishReason: "tool_calls"}},
+	}
+}
+
+func toolCall(id, name, args string) llm.ToolCall {
+	return llm.ToolCall{
+		ID:       id,
+		Type:     "function",
+		Function: llm.FunctionCall{Name: name, Arguments: args},
+	}
+}
+
+type appDeps struct {
+	User string
+}
+
+func TestAgent_RunInjectsDeps(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("whoami", "Name of the current user", func(ctx context.Context) (string, error) {
+		deps := tools.FromContext(ctx).(*appDeps)
+		return deps.User, nil
+	})
+
+	client, api := newMockClient(t,
+		toolCallResponse(toolCall("call_1", "whoami", "{}")),
+		textResponse("You are carol"),
+	)
+
+	myAgent := New(client, "test-model", WithTools(registry), WithDeps(&appDeps{User: "carol"}))
+
+	got, err := myAgent.Run(context.Background(), "who am i?")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got != "You are carol" {
+		t.Errorf("got %q want %q", got, "You are carol")
+	}
+
+	// the second request should carry the tool result built from the injected deps
+	if len(api.requests) != 2 {
+		t.Fatalf("got %d requests want 2", len(api.requests))
+	}
+	msgs := api.requests[1].Messages
+	last := msgs[len(msgs)-1]
+	if last.Role != "tool" || last.ToolCallID != "call_1" || last.Content != "carol" {
+		t.Errorf("got last message %+v, want the tool result %q for call_1", last, "carol")
+	}
+
+	if len(api.requests[0].Tools) != 1 || api.requests[0].Tools[0].Function.Name != "whoami" {
+		t.Errorf("got tools %+v, want whoami to be sent", api.requests[0].Tools)
+	}
+}
+
+func TestAgent_WithInitialHistory(t *testing.T) {
+	saved := []llm.Message{
+		llm.NewUserMessage("My name is Carol"),
+		llm.NewAssistantMessage("Hi Carol"),
+	}
+
+	t.Run("clean history gets the system prompt in front", func(t *testing.T) {
+		myAgent := New(nil, "test-model", WithSystemPrompts("be nice"), WithInitialHistory(saved))
+
+		if len(myAgent.History) != 3 {
+			t.Fatalf("got %d messages want 3", len(myAgent.History))
+		}
+		if myAgent.History[0].Role != "system" || myAgent.History[0].Content != "be nice" {
+			t.Errorf("got first message %+v, want the system prompt", myAgent.History[0])
+		}
+		if myAgent.History[1].Content != "My name is Carol" {
+			t.Errorf("got %+v, want the seeded user message second", myAgent.History[1])
+		}
+	})
+
+	t.Run("history with its own system message wins", func(t *testing.T) {
+		withSystem := append([]llm.Message{llm.NewSystemMessage("talk like a pirate")}, saved...)
+
+		myAgent := New(nil, "test-model", WithSystemPrompts("be nice"), WithInitialHistory(withSystem))
+
+		systemCount := 0
+		for _, msg := range myAgent.History {
+			if msg.Role == "system" {
+				systemCount++
+			}
+		}
+		if systemCount != 1 {
+			t.Errorf("got %d system messages want 1", systemCount)
+		}
+		if myAgent.History[0].Content != "talk like a pirate" || myAgent.SystemPrompt != "talk like a pirate" {
+			t.Errorf("got %q / %q, want the seeded system prompt", myAgent.History[0].Content, myAgent.SystemPrompt)
+		}
+	})
+
+	t.Run("the caller's slice is copied", func(t *testing.T) {
+		myAgent := New(nil, "test-model", WithInitialHistory(saved))
+		myAgent.History[0].Content = "changed"
+
+		if saved[0].Content != "My name is Carol" {
+			t.Error("changing the agent history changed the caller's slice")
+		}
+	})
+}
+
+func TestAgent_SetSystemPrompt(t *testing.T) {
+	client, api := newMockClient(t, textResponse("Ahoy"), textResponse("Good day"))
+	myAgent := New(client, "test-model", WithSystemPrompts("talk like a pirate"))
+
+	myAgent.Run(context.Background(), "hello")
+	myAgent.SetSystemPrompt("talk like a butler")
+	myAgent.Run(context.Background(), "hello again")
+
+	sent := api.requests[1].Messages
+	if sent[0].Role != "system" || sent[0].Content != "talk like a butler" {
+		t.Errorf("got first message %+v want the new system prompt", sent[0])
+	}
+
+	systems := 0
+	for _, msg := range sent {
+		if msg.Role == "system" {
+			systems++
+		}
+	}
+	if systems != 1 {
+		t.Errorf("got %d system messages want 1", systems)
+	}
+	// system, hello, Ahoy, hello again
+	if len(sent) != 4 || sent[1].Content != "hello" || sent[2].Content != "Ahoy" {
+		t.Errorf("got %+v, the rest of the history should be untouched", sent)
+	}
+
+	t.Run("inserted when absent", func(t *testing.T) {
+		plain := New(nil, "test-model")
+		plain.History = append(plain.History, llm.NewUserMessage("hi"))
+
+		plain.SetSystemPrompt("be nice")
+		if len(plain.History) != 2 || plain.History[0].Role != "system" || plain.History[1].Content != "hi" {
+			t.Errorf("got %+v want the system prompt in front of the user message", plain.History)
+		}
+	})
+}
+
+func TestAgent_WithMaxToolResultBytes(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("read_file", "Read a file", func() string {
+		return strings.Repeat("é", 1000) // 2000 bytes
+	})
+
+	client, _ := newMockClient(t,
+		toolCallResponse(toolCall("call_1", "read_file", `{}`)),
+		textResponse("done"),
+	)
+	myAgent := New(client, "test-model", WithTools(registry), WithMaxToolResultBytes(100))
+
+	if _, err := myAgent.Run(context.Background(), "read it"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// user, tool call, tool result, answer
+	result := myAgent.History[2]
+	if result.Role != "tool" {
+		t.Fatalf("got %+v want the tool result", result)
+	}
+	if len(result.Content) > 100 || !strings.HasSuffix(result.Content, "...[truncated]") {
+		t.Errorf("got %d bytes ending in %q, want at most 100 with the marker", len(result.Content), result.Content[len(result.Content)-20:])
+	}
+	if !utf8.ValidString(result.Content) {
+		t.Error("truncation split a character")
+	}
+}
+
+func TestAgent_truncateToolResult(t *testing.T) {
+	output := strings.Repeat("é", 20) // 40 bytes
+	for _, limit := range []int{1, 5, 13, 14, 15, 39} {
+		myAgent := New(nil, "test-model", WithMaxToolResultBytes(limit))
+
+		got := myAgent.truncateToolResult(output)
+		if len(got) > limit {
+			t.Errorf("limit %d: got %d bytes %q", limit, len(got), got)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("limit %d: truncation split a character", limit)
+		}
+	}
+}
+
+func TestAgent_RunContextDoneDuringToolCall(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	registry := tools.NewRegistry()
+	// the slow search outlives the caller's patience, the second tool never gets to run
+	registry.Register("search", "Search the web", func(ctx context.Context) (string, error) {
+		cancel()
+		return "", ctx.Err()
+	})
+	registry.Register("summarize", "Summarize", func() string { return "summary" })
+
+	client, _ := newMockClient(t, toolCallResponse(
+		toolCall("call_1", "search", `{}`),
+		toolCall("call_2", "summarize", `{}`),
+	))
+	myAgent := New(client, "test-model", WithTools(registry))
+
+	_, err := myAgent.Run(ctx, "research this")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v want it to wrap context.Canceled", err)
+	}
+	if !strings.Contains(err.Error(), "tool calls") {
+		t.Errorf("got %q want the error to name the tool phase", err)
+	}
+
+	// user, tool call, and one result per call so the history is still valid for the next Run
+	if len(myAgent.History) != 4 {
+		t.Fatalf("got %d messages want 4: %+v", len(myAgent.History), myAgent.History)
+	}
+	for _, result := range myAgent.History[2:] {
+		if !strings.Contains(result.Content, "wrap up") || strings.Contains(result.Content, "fix your arguments") {
+			t.Errorf("got tool result %q want the deadline explanation", result.Content)
+		}
+	}
+
+	t.Run("api phase", func(t *testing.T) {
+		done, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		client, _ := newMockClient(t, textResponse("too late"))
+		_, err := New(client, "test-model").Run(done, "hi")
+		if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "LLM call") {
+			t.Errorf("got %v want the api phase named and context.Canceled wrapped", err)
+		}
+	})
+}
+
+func TestAgent_WithMaxConcurrentToolCalls(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+
+	registry := tools.NewRegistry()
+	registry.RegisterWithParams("fetch", "Fetch a page", func(page int) string {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return fmt.Sprintf("page %d", page)
+	}, "page")
+
+	var calls []llm.ToolCall
+	for i := range 5 {
+		calls = append(calls, toolCall(fmt.Sprintf("call_%d", i), "fetch", fmt.Sprintf(`{"page":%d}`, i)))
+	}
+	client, _ := newMockClient(t, toolCallResponse(calls...), textResponse("done"))
+	myAgent := New(client, "test-model", WithTools(registry), WithMaxConcurrentToolCalls(2))
+
+	if _, err := myAgent.Run(context.Background(), "fetch five pages"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if peak > 2 {
+		t.Errorf("got %d tools running at once want at most 2", peak)
+	}
+	if peak < 2 {
+		t.Errorf("got %d tools running at once, the calls should have run in parallel", peak)
+	}
+
+	// user, tool call, five results in the order they were asked for, answer
+	for i, result := range myAgent.History[2:7] {
+		if want := fmt.Sprintf("page %d", i); result.ToolCallID != calls[i].ID || result.Content != want {
+			t.Errorf("result %d: got %+v want %q for %s", i, result, want, calls[i].ID)
+		}
+	}
+}
+
+func TestAgent_RetryBudget(t *testing.T) {
+	t.Run("api retries stop at the budget", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client, err := llm.NewClient("test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.BaseURL = server.URL
+		client.MaxRetries = 10
+		client.Backoff = llm.Backoff{}
+
+		myAgent := New(client, "test-model", WithRetryBudget(3))
+		_, err = myAgent.Run(context.Background(), "hi")
+
+		if !errors.Is(err, llm.ErrRetryBudgetExhausted) {
+			t.Errorf("got %v want ErrRetryBudgetExhausted", err)
+		}
+		// the first attempt is free, the budget is for the retries after it
+		if retries := attempts.Load() - 1; retries != 3 {
+			t.Errorf("got %d retries want the budget of 3", retries)
+		}
+	})
+
+	t.Run("tool retries share the same budget", func(t *testing.T) {
+		var attempts int
+		registry := tools.NewRegistry(tools.WithToolRetry(tools.RetryPolicy{MaxAttempts: 10}))
+		registry.Register("flaky", "", func() (string, error) {
+			attempts++
+			return "", tools.Retryable(errors.New("upstream timeout"))
+		})
+
+		client, _ := newMockClient(t,
+			toolCallResponse(toolCall("call_1", "flaky", `{}`)),
+			textResponse("The service is down."),
+		)
+		myAgent := New(client, "test-model", WithTools(registry), WithRetryBudget(2))
+
+		if _, err := myAgent.Run(context.Background(), "try it"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if attempts != 3 {
+			t.Errorf("got %d tool attempts want 1 + a budget of 2", attempts)
+		}
+
+		result := myAgent.History[len(myAgent.History)-2]
+		if !strings.Contains(result.Content, "retry budget exhausted") {
+			t.Errorf("got tool result %q want it to mention the budget", result.Content)
+		}
+	})
+}
+
+func TestAgent_WithRetryOnEmpty(t *testing.T) {
+	t.Run("retries until there is content", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse(""), textResponse("Paris"))
+		myAgent := New(client, "test-model", WithRetryOnEmpty())
+
+		reply, err := myAgent.Run(context.Background(), "capital of France?")
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if reply != "Paris" {
+			t.Errorf("got %q want %q", reply, "Paris")
+		}
+		if len(api.requests) != 2 {
+			t.Errorf("got %d requests want 2", len(api.requests))
+		}
+		// the empty answer never made it into history
+		if len(myAgent.History) != 2 {
+			t.Errorf("got history %+v want the question and the answer", myAgent.History)
+		}
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse(""), textResponse("  \n"), textResponse(""), textResponse("late"))
+		myAgent := New(client, "test-model", WithRetryOnEmpty(), WithMaxRetries(2))
+
+		_, err := myAgent.Run(context.Background(), "capital of France?")
+		if !errors.Is(err, ErrEmptyResponse) {
+			t.Errorf("got %v want ErrEmptyResponse", err)
+		}
+		if len(api.requests) != 3 {
+			t.Errorf("got %d requests want 3, one try and two retries", len(api.requests))
+		}
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		client, _ := newMockClient(t, textResponse(""))
+		myAgent := New(client, "test-model")
+
+		if reply, err := myAgent.Run(context.Background(), "hi"); reply != "" || err != nil {
+			t.Errorf("got %q, %v want the empty reply as before", reply, err)
+		}
+	})
+}
+
+func TestAgent_WithToolErrorFormatter(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("divide", "", func() (string, error) { return "", errors.New("division by zero") })
+
+	format := func(toolName string, err error) string {
+		return fmt.Sprintf("[%s failed] %v", toolName, err)
+	}
+
+	for name, tc := range map[string]struct {
+		opts []Option
+		want string
+	}{
+		"custom":  {[]Option{WithTools(registry), WithToolErrorFormatter(format)}, "[divide failed] division by zero"},
+		"default": {[]Option{WithTools(registry)}, "Error executing tool: division by zero. Please fix your arguments."},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, _ := newMockClient(t,
+				toolCallResponse(toolCall("call_1", "divide", `{}`)),
+				textResponse("Can't divide by zero."),
+			)
+			myAgent := New(client, "test-model", tc.opts...)
+			myAgent.Run(context.Background(), "1/0?")
+
+			result := myAgent.History[len(myAgent.History)-2]
+			if !result.IsToolResult() || result.Content != tc.want {
+				t.Errorf("got %+v want content %q", result, tc.want)
+			}
+		})
+	}
+}
+
+func TestAgent_SchemaViolationsReachTheModel(t *testing.T) {
+	registry := tools.NewRegistry(tools.WithValidateResponseSchema())
+	registry.Register("forecast", "", func(args struct {
+		Days int `json:"days"`
+	}) string {
+		return "sunny"
+	})
+
+	client, _ := newMockClient(t,
+		toolCallResponse(toolCall("call_1", "forecast", `{"days": "three"}`)),
+		textResponse("Let me try that again."),
+	)
+	myAgent := New(client, "test-model", WithTools(registry))
+	myAgent.Run(context.Background(), "forecast?")
+
+	result := myAgent.History[len(myAgent.History)-2]
+	if !result.IsToolResult() || !strings.Contains(result.Content, "days: got string want integer") {
+		t.Errorf("got %+v want the schema error as the tool result", result)
+	}
+}
+
+func TestAgent_TotalCost(t *testing.T) {
+	// /models hangs until released, the agent must stay usable meanwhile
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(fetching)
+		<-release
+		w.Write([]byte(`{"data":[{"id":"test-model","pricing":{"prompt":"0.000001","completion":"0.000002"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := llm.NewClient("test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+
+	myAgent := New(client, "test-model")
+	resp := textResponse("hi")
+	resp.Model = "test-model"
+	resp.Usage = llm.Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+	if err := myAgent.AppendResponse(&resp); err != nil {
+		t.Fatalf("AppendResponse failed: %v", err)
+	}
+
+	type costResult struct {
+		cost float64
+		err  error
+	}
+	result := make(chan costResult, 1)
+	go func() {
+		cost, err := myAgent.TotalCost()
+		result <- costResult{cost, err}
+	}()
+
+	<-fetching
+	read := make(chan struct{})
+	go func() {
+		myAgent.LastLogprobs()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the agent was locked while TotalCost fetched pricing")
+	}
+	close(release)
+
+	got := <-result
+	if got.err != nil {
+		t.Fatalf("TotalCost failed: %v", got.err)
+	}
+	// 1000 * 0.000001 + 500 * 0.000002
+	if want := 0.002; got.cost < want-1e-12 || got.cost > want+1e-12 {
+		t.Errorf("got %v want %v", got.cost, want)
+	}
+}
@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"my_agent/llm"
+)
+
+// ErrToolsUnsupported means the agent has tools but its model can't do function calling
+var ErrToolsUnsupported = errors.New("model does not support tool calling")
+
+// WithToolFallback makes a model without tool support still usable
+// instead of failing with ErrToolsUnsupported the tools are left out of the request
+// and described in the system prompt, so the model at least knows what exists
+func WithToolFallback() Option {
+	return func(a *Agent) {
+		a.ToolFallback = true
+	}
+}
+
+// gateTools checks the request against what the client knows about the model
+// models the client knows nothing about are let through, see llm.Client.SupportsTools
+func (a *Agent) gateTools(req *llm.ChatRequest) error {
+	if len(req.Tools) == 0 {
+		return nil
+	}
+	if supported, known := a.client.SupportsTools(req.Model); supported || !known {
+		return nil
+	}
+
+	if !a.ToolFallback {
+		return fmt.Errorf("%w: %s", ErrToolsUnsupported, req.Model)
+	}
+
+	req.Tools = nil
+	req.Messages = withToolList(req.Messages, a.Tools.Describe())
+	return nil
+}
+
+// withToolList returns msgs with the tool list added to the system message (or a new one in front)
+// the history itself is left alone, this only changes what is sent
+func withToolList(msgs []llm.Message, list string) []llm.Message {
+	note := "You can't call tools directly, but these tools exist. Tell the user which one would help and with what arguments:\n" + list
+
+	out := make([]llm.Message, 0, len(msgs)+1)
+	if len(msgs) > 0 && msgs[0].IsSystem() {
+		system := msgs[0]
+		system.Content += "\n\n" + note
+		out = append(out, system)
+		msgs = msgs[1:]
+	} else {
+		out = append(out, llm.NewSystemMessage(note))
+	}
+	return append(out, msgs...)
+}
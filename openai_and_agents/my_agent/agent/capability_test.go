@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"my_agent/llm"
+	"my_agent/tools"
+	"strings"
+	"testing"
+)
+
+func TestAgent_ToolCapabilityGate(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("get_time", "Get the current time", func() string { return "noon" })
+
+	// what /models would say about a model without function calling
+	tinyModel := llm.ModelInfo{ID: "tiny/model", SupportedParameters: []string{"temperature"}}
+
+	t.Run("errors clearly by default", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		client.SetModelInfo(tinyModel)
+		myAgent := New(client, "tiny/model", WithTools(registry))
+
+		_, err := myAgent.Run(context.Background(), "what time is it?")
+		if !errors.Is(err, ErrToolsUnsupported) {
+			t.Errorf("got %v want ErrToolsUnsupported", err)
+		}
+		if len(api.requests) != 0 {
+			t.Errorf("got %d requests, nothing should have been sent", len(api.requests))
+		}
+	})
+
+	t.Run("fallback describes the tools in the prompt", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("use get_time"))
+		client.SetModelInfo(tinyModel)
+		myAgent := New(client, "tiny/model", WithTools(registry), WithToolFallback(), WithSystemPrompts("Be brief."))
+
+		if _, err := myAgent.Run(context.Background(), "what time is it?"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		req := api.requests[0]
+		if len(req.Tools) != 0 {
+			t.Errorf("got %d tools in the request want none", len(req.Tools))
+		}
+		system := req.Messages[0].Content
+		if !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, "- get_time: Get the current time") {
+			t.Errorf("got system prompt %q want the original prompt plus the tool list", system)
+		}
+		if myAgent.History[0].Content != "Be brief." {
+			t.Errorf("got history system prompt %q, the fallback should not touch the history", myAgent.History[0].Content)
+		}
+	})
+
+	t.Run("unknown models are let through", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "mystery/model", WithTools(registry))
+
+		if _, err := myAgent.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(api.requests[0].Tools) != 1 {
+			t.Errorf("got %d tools want 1", len(api.requests[0].Tools))
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// RunEnsemble sends the same prompt to every agent at once (say one per model) and collects all the answers
+// results and errors line up with agents, replies[i] and errs[i] belong to agents[i]
+// each agent still keeps its own history, so don't pass the same agent twice
+func RunEnsemble(ctx context.Context, prompt string, agents ...*Agent) ([]string, []error) {
+	replies := make([]string, len(agents))
+	errs := make([]error, len(agents))
+
+	var wg sync.WaitGroup
+	for i, a := range agents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every goroutine writes only its own index so no lock is needed
+			replies[i], errs[i] = a.Run(ctx, prompt)
+		}()
+	}
+	wg.Wait()
+
+	return replies, errs
+}
+
+// Majority picks the most common answer out of results, for self consistency voting over RunEnsemble
+// answers are compared with case and whitespace normalized, the one returned is the first as it was written
+// empty answers (what a failed run leaves behind) don't vote, a tie goes to the answer seen first
+func Majority(results []string) (string, int) {
+	votes := make(map[string]int)
+	first := make(map[string]string)
+	var order []string
+
+	for _, result := range results {
+		key := strings.ToLower(strings.Join(strings.Fields(result), " "))
+		if key == "" {
+			continue
+		}
+		if _, seen := first[key]; !seen {
+			first[key] = result
+			order = append(order, key)
+		}
+		votes[key]++
+	}
+
+	best, count := "", 0
+	for _, key := range order {
+		if votes[key] > count {
+			best, count = first[key], votes[key]
+		}
+	}
+	return best, count
+}
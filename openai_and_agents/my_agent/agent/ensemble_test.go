@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunEnsemble(t *testing.T) {
+	clientA, _ := newMockClient(t, textResponse("Paris"))
+	clientB, _ := newMockClient(t, textResponse("paris"))
+	clientC, _ := newMockClient(t) // no canned response, the api answers 400
+
+	agents := []*Agent{
+		New(clientA, "model-a"),
+		New(clientB, "model-b"),
+		New(clientC, "model-c"),
+	}
+
+	replies, errs := RunEnsemble(context.Background(), "Capital of France?", agents...)
+
+	if len(replies) != 3 || len(errs) != 3 {
+		t.Fatalf("got %d replies and %d errors want 3 of each", len(replies), len(errs))
+	}
+	if replies[0] != "Paris" || replies[1] != "paris" {
+		t.Errorf("got %q, results should be in agent order", replies)
+	}
+	if errs[0] != nil || errs[1] != nil {
+		t.Errorf("got errors %v for the working agents", errs[:2])
+	}
+	if errs[2] == nil {
+		t.Error("expected an error from the failing agent")
+	}
+}
+
+func TestMajority(t *testing.T) {
+	got, votes := Majority([]string{"Paris", "  paris ", "Lyon"})
+	if got != "Paris" || votes != 2 {
+		t.Errorf("got (%q, %d) want (%q, 2)", got, votes, "Paris")
+	}
+
+	t.Run("empty answers don't vote", func(t *testing.T) {
+		got, votes := Majority([]string{"", "Lyon", "", " "})
+		if got != "Lyon" || votes != 1 {
+			t.Errorf("got (%q, %d) want (%q, 1)", got, votes, "Lyon")
+		}
+	})
+
+	t.Run("nothing to vote on", func(t *testing.T) {
+		if got, votes := Majority(nil); got != "" || votes != 0 {
+			t.Errorf("got (%q, %d) want (\"\", 0)", got, votes)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+)
+
+type EventType string
+
+const (
+	// text the model wrote alongside its tool calls (the "let me check that" bit)
+	EventAssistantText EventType = "assistant_text"
+	// a tool is about to run, ToolCall says which one with what arguments
+	EventToolCallStarted EventType = "tool_call_started"
+	// a tool is done, Content is what goes back to the model and Err is set if it failed
+	EventToolCallFinished EventType = "tool_call_finished"
+	// the final answer, always the last event of a successful run
+	EventFinal EventType = "final"
+)
+
+// AgentEvent is one step of a Run, these are whole steps not tokens (that is RunStream)
+type AgentEvent struct {
+	Type     EventType
+	Content  string
+	ToolCall *llm.ToolCall
+	Err      error
+}
+
+// WithFinalOnly hides the text the model writes between tool calls ("let me check that"),
+// RunEvents then never sends EventAssistantText and only the final answer is surfaced
+// the text stays in History, the model still needs to see what it said
+// Run itself always returned just the final turn, so this only changes what the events show
+func WithFinalOnly() Option {
+	return func(a *Agent) {
+		a.FinalOnly = true
+	}
+}
+
+// RunEvents is Run but reporting every step as it happens, good for a ui showing "calling get_weather..."
+// the events channel closes when the run is over, then the error channel has the run error if there was one
+// stop reading the events and cancel ctx together, otherwise the run blocks on the next event
+// the read only methods (Transcript, PendingToolCalls, LastLogprobs, TotalCost) are fine inside the loop,
+// the ones changing History (AddMessage, Reset, ResolveToolCall ...) wait for the run and would block it
+func (a *Agent) RunEvents(ctx context.Context, usrMsg string) (<-chan AgentEvent, <-chan error) {
+	events := make(chan AgentEvent)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		emit := func(event AgentEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		}
+
+		if _, err := a.runSession(ctx, usrMsg, emit); err != nil {
+			errs <- err
+		}
+	}()
+
+	return events, errs
+}
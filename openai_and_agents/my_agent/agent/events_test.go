@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"my_agent/tools"
+	"testing"
+)
+
+func TestAgent_RunEvents(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("get_weather", "Get current weather", func(args struct {
+		City string `json:"city"`
+	}) string {
+		return "sunny in " + args.City
+	})
+
+	thinking := toolCallResponse(toolCall("call_1", "get_weather", `{"city":"Paris"}`))
+	thinking.Choices[0].Message.Content = "Let me check."
+
+	client, _ := newMockClient(t, thinking, textResponse("It is sunny in Paris"))
+	myAgent := New(client, "test-model", WithTools(registry))
+
+	events, errs := myAgent.RunEvents(context.Background(), "weather in paris?")
+
+	var got []AgentEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("RunEvents failed: %v", err)
+	}
+
+	want := []EventType{EventAssistantText, EventToolCallStarted, EventToolCallFinished, EventFinal}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events %+v want %v", len(got), got, want)
+	}
+	for i, event := range got {
+		if event.Type != want[i] {
+			t.Errorf("event %d: got %q want %q", i, event.Type, want[i])
+		}
+	}
+
+	if got[1].ToolCall.Function.Name != "get_weather" {
+		t.Errorf("got started event for %q want get_weather", got[1].ToolCall.Function.Name)
+	}
+	if got[2].Content != "sunny in Paris" || got[2].Err != nil {
+		t.Errorf("got finished event %+v", got[2])
+	}
+	if got[3].Content != "It is sunny in Paris" {
+		t.Errorf("got final %q", got[3].Content)
+	}
+}
+
+func TestAgent_FinalOnly(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("get_weather", "", func() string { return "sunny" })
+
+	thinking := toolCallResponse(toolCall("call_1", "get_weather", `{}`))
+	thinking.Choices[0].Message.Content = "Let me check."
+
+	client, _ := newMockClient(t, thinking, textResponse("It is sunny."))
+	myAgent := New(client, "test-model", WithTools(registry), WithFinalOnly())
+
+	events, errs := myAgent.RunEvents(context.Background(), "weather?")
+
+	var texts []string
+	for event := range events {
+		if event.Type == EventAssistantText || event.Type == EventFinal {
+			texts = append(texts, event.Content)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("RunEvents failed: %v", err)
+	}
+
+	if len(texts) != 1 || texts[0] != "It is sunny." {
+		t.Errorf("got surfaced text %q want only the final answer", texts)
+	}
+
+	// the interim text is still part of the conversation
+	if got := myAgent.History[len(myAgent.History)-3].Content; got != "Let me check." {
+		t.Errorf("got %q in history want the interim text kept", got)
+	}
+}
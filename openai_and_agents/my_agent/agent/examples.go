@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"my_agent/llm"
+	"slices"
+)
+
+// LoadExamples seeds few-shot examples from r, a json array like
+// [{"role": "user", "content": "2+2"}, {"role": "assistant", "content": "4"}]
+// they go right after the system prompt, before any conversation that is already there
+// only user and assistant turns are allowed, starting with user, alternating and ending on assistant
+// so the model never sees an example question left unanswered
+// nothing is added when the examples are invalid
+func (a *Agent) LoadExamples(r io.Reader) error {
+	var examples []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r).Decode(&examples); err != nil {
+		return fmt.Errorf("unable to decode the examples: %w", err)
+	}
+	if len(examples) == 0 {
+		return fmt.Errorf("no examples to load")
+	}
+
+	msgs := make([]llm.Message, 0, len(examples))
+	for i, example := range examples {
+		want := "user"
+		if i%2 == 1 {
+			want = "assistant"
+		}
+		if example.Role != want {
+			return fmt.Errorf("example %d: got role %q want %q, examples alternate user and assistant", i, example.Role, want)
+		}
+		if example.Content == "" {
+			return fmt.Errorf("example %d: content is empty", i)
+		}
+
+		if want == "user" {
+			msgs = append(msgs, llm.NewUserMessage(example.Content))
+		} else {
+			msgs = append(msgs, llm.NewAssistantMessage(example.Content))
+		}
+	}
+	if len(msgs)%2 != 0 {
+		return fmt.Errorf("the last example is a user turn without an answer")
+	}
+
+	a.lockRun()
+	defer a.unlockRun()
+
+	at := 0
+	if len(a.History) > 0 && a.History[0].IsSystem() {
+		at = 1
+	}
+	a.History = slices.Insert(a.History, at, msgs...)
+	return nil
+}
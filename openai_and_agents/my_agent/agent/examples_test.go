@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAgent_LoadExamples(t *testing.T) {
+	examples := `[
+		{"role": "user", "content": "2+2"},
+		{"role": "assistant", "content": "4"},
+		{"role": "user", "content": "3*3"},
+		{"role": "assistant", "content": "9"}
+	]`
+
+	t.Run("seeded after the system prompt", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("10"))
+		myAgent := New(client, "test-model", WithSystemPrompts("answer with a number"))
+
+		if err := myAgent.LoadExamples(strings.NewReader(examples)); err != nil {
+			t.Fatalf("LoadExamples failed: %v", err)
+		}
+		myAgent.Run(context.Background(), "5+5")
+
+		sent := api.requests[0].Messages
+		var got []string
+		for _, msg := range sent {
+			got = append(got, msg.Role+":"+msg.Content)
+		}
+		want := []string{"system:answer with a number", "user:2+2", "assistant:4", "user:3*3", "assistant:9", "user:5+5"}
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("sent %v want %v", got, want)
+		}
+	})
+
+	t.Run("invalid examples", func(t *testing.T) {
+		cases := map[string]string{
+			"not json":          `role: user`,
+			"empty":             `[]`,
+			"system role":       `[{"role": "system", "content": "x"}, {"role": "assistant", "content": "y"}]`,
+			"starts with reply": `[{"role": "assistant", "content": "4"}, {"role": "user", "content": "2+2"}]`,
+			"two users":         `[{"role": "user", "content": "a"}, {"role": "user", "content": "b"}]`,
+			"unanswered":        `[{"role": "user", "content": "2+2"}, {"role": "assistant", "content": "4"}, {"role": "user", "content": "3*3"}]`,
+			"empty content":     `[{"role": "user", "content": ""}, {"role": "assistant", "content": "4"}]`,
+		}
+
+		for name, input := range cases {
+			client, _ := newMockClient(t)
+			myAgent := New(client, "test-model", WithSystemPrompts("answer with a number"))
+
+			if err := myAgent.LoadExamples(strings.NewReader(input)); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			if len(myAgent.History) != 1 {
+				t.Errorf("%s: got history %+v want it untouched", name, myAgent.History)
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"fmt"
+	"my_agent/llm"
+	"slices"
+	"strings"
+)
+
+// ImportHistory appends the conversation of another agent to this one, for handing a task over
+// system messages are skipped unless includeSystem is set, this agent keeps its own instructions
+// with includeSystem they are merged into this agent's system prompt (after its own), never added mid history
+//
+// tool call ids that already exist in this history get a fresh id (on the call and on its result)
+// so two "call_1" from different agents can't get mixed up
+// results without a matching call and calls without a result are dropped, providers reject both
+func (a *Agent) ImportHistory(from *Agent, includeSystem bool) {
+	if from == nil || from == a {
+		return
+	}
+
+	// from is copied under its own lock first, the two agents are never locked together
+	// so a.ImportHistory(b) and b.ImportHistory(a) at the same time can't deadlock
+	// a Run of from may be halfway through a tool chain, its unanswered calls are dropped like any other
+	from.mu.Lock()
+	history := slices.Clone(from.History)
+	from.mu.Unlock()
+
+	a.lockRun()
+	defer a.unlockRun()
+
+	taken := make(map[string]bool)
+	for _, msg := range a.History {
+		for _, call := range msg.ToolCalls {
+			taken[call.ID] = true
+		}
+	}
+
+	// which calls actually got answered, calls left hanging are dropped below
+	answered := make(map[string]bool)
+	for _, msg := range history {
+		if msg.IsToolResult() {
+			answered[msg.ToolCallID] = true
+		}
+	}
+
+	var prompts []string
+	for _, msg := range a.History {
+		if msg.IsSystem() {
+			prompts = append(prompts, msg.Content)
+		}
+	}
+	ownPrompts := len(prompts)
+
+	renamed := make(map[string]string)
+	for _, msg := range history {
+		switch {
+		case msg.IsSystem():
+			if includeSystem && !slices.Contains(prompts, msg.Content) {
+				prompts = append(prompts, msg.Content)
+			}
+			continue
+
+		case msg.IsToolResult():
+			id, ok := renamed[msg.ToolCallID]
+			if !ok {
+				continue // no call for it (or the call was dropped)
+			}
+			msg.ToolCallID = id
+
+		case msg.IsToolCall():
+			calls := make([]llm.ToolCall, 0, len(msg.ToolCalls))
+			for _, call := range msg.ToolCalls {
+				if !answered[call.ID] {
+					continue
+				}
+				id := uniqueCallID(call.ID, taken)
+				taken[id] = true
+				renamed[call.ID] = id
+
+				call.ID = id
+				calls = append(calls, call)
+			}
+			if len(calls) == 0 && msg.Content == "" {
+				continue
+			}
+			msg.ToolCalls = calls
+		}
+
+		a.History = append(a.History, msg)
+	}
+
+	// SetSystemPrompt keeps exactly one system message, at the front
+	if len(prompts) > ownPrompts {
+		a.setSystemPrompt(strings.Join(prompts, "\n\n"))
+	}
+}
+
+// uniqueCallID returns id if it is free, otherwise id with the first free numeric suffix
+func uniqueCallID(id string, taken map[string]bool) string {
+	if !taken[id] {
+		return id
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", id, n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// AppendResponse folds a response from calling the client directly into this agent's history
+// it takes the first choice and insists it is an assistant message, the usage counts towards TotalCost
+// tool calls in it are left pending, answer them with ResolveToolCall before the next Run
+func (a *Agent) AppendResponse(resp *llm.ChatResponse) error {
+	if resp == nil || len(resp.Choices) == 0 {
+		return fmt.Errorf("response has no choices to append")
+	}
+
+	a.lockRun()
+	defer a.unlockRun()
+
+	msg := resp.Choices[0].Message
+	if !msg.IsAssistant() {
+		return fmt.Errorf("can only append an assistant message, got role %q", msg.Role)
+	}
+	if len(a.pending) > 0 {
+		return fmt.Errorf("%w: %d tool calls still need ResolveToolCall", ErrWaitingForTools, len(a.pending))
+	}
+
+	a.appendHistory(msg)
+	a.usage[resp.Model] = a.usage[resp.Model].Add(resp.Usage)
+	if msg.IsToolCall() {
+		a.pending = slices.Clone(msg.ToolCalls)
+	} else {
+		a.lastLogprobs = resp.Choices[0].Logprobs
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+	"my_agent/tools"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAgent_ImportHistory(t *testing.T) {
+	// the researcher looked something up with a tool, the writer already used the same call id
+	researcher := New(nil, "test-model", WithSystemPrompts("You research things."))
+	researcher.History = append(researcher.History,
+		llm.NewUserMessage("Weather in Paris?"),
+		llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_1", "get_weather", `{"city":"Paris"}`)}),
+		llm.NewToolResult("call_1", "sunny"),
+		llm.NewAssistantMessage("It is sunny in Paris."),
+		// a call the researcher never got an answer for, it would break the merged history
+		llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_2", "get_weather", `{"city":"Oslo"}`)}),
+	)
+
+	writer := New(nil, "test-model", WithSystemPrompts("You write things."))
+	writer.History = append(writer.History,
+		llm.NewUserMessage("What time is it?"),
+		llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_1", "get_time", `{}`)}),
+		llm.NewToolResult("call_1", "noon"),
+		llm.NewAssistantMessage("It is noon."),
+	)
+	before := len(writer.History)
+
+	writer.ImportHistory(researcher, false)
+
+	imported := writer.History[before:]
+	if len(imported) != 4 {
+		t.Fatalf("got %d imported messages want 4 (no system, no dangling call): %+v", len(imported), imported)
+	}
+	if writer.History[0].Content != "You write things." {
+		t.Errorf("got system prompt %q, the writer should keep its own", writer.History[0].Content)
+	}
+
+	callID := imported[1].ToolCalls[0].ID
+	if callID == "call_1" {
+		t.Error("the clashing call id was not renamed")
+	}
+	if imported[2].ToolCallID != callID {
+		t.Errorf("got tool result for %q want it to follow the renamed call %q", imported[2].ToolCallID, callID)
+	}
+
+	assertValidToolChain(t, writer.History)
+
+	t.Run("include system", func(t *testing.T) {
+		a := New(nil, "test-model")
+		a.ImportHistory(researcher, true)
+
+		if a.History[0].Role != "system" || a.History[0].Content != "You research things." {
+			t.Errorf("got first message %+v want the researcher's system prompt", a.History[0])
+		}
+		assertValidToolChain(t, a.History)
+	})
+
+	t.Run("include system merges into an existing prompt", func(t *testing.T) {
+		a := New(nil, "test-model", WithSystemPrompts("You write things."))
+		a.ImportHistory(researcher, true)
+
+		systems := 0
+		for _, msg := range a.History {
+			if msg.IsSystem() {
+				systems++
+			}
+		}
+		if systems != 1 {
+			t.Fatalf("got %d system messages want exactly one", systems)
+		}
+		want := "You write things.\n\nYou research things."
+		if a.History[0].Content != want || a.SystemPrompt != want {
+			t.Errorf("got system prompt %q want %q", a.History[0].Content, want)
+		}
+		assertValidToolChain(t, a.History)
+	})
+
+	t.Run("two agents importing from each other", func(t *testing.T) {
+		a := New(nil, "test-model")
+		a.AddMessage(llm.NewUserMessage("from a"))
+		b := New(nil, "test-model")
+		b.AddMessage(llm.NewUserMessage("from b"))
+
+		done := make(chan struct{})
+		go func() {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() { defer wg.Done(); a.ImportHistory(b, false) }()
+			go func() { defer wg.Done(); b.ImportHistory(a, false) }()
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the two imports deadlocked")
+		}
+	})
+
+	t.Run("from a tool of the agent being copied", func(t *testing.T) {
+		client, _ := newMockClient(t,
+			toolCallResponse(toolCall("call_1", "hand_over", `{}`)),
+			textResponse("handed over"),
+		)
+		registry := tools.NewRegistry()
+		var from *Agent
+		to := New(nil, "test-model")
+		registry.Register("hand_over", "Hand the task over", func() string {
+			to.ImportHistory(from, false)
+			return "ok"
+		})
+		from = New(client, "test-model", WithTools(registry))
+
+		if _, err := from.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		// the call was still unanswered at the time, so only the user message came over
+		if len(to.History) != 1 || to.History[0].Content != "hi" {
+			t.Errorf("got %+v want just the user message", to.History)
+		}
+	})
+}
+
+func TestAgent_AppendResponse(t *testing.T) {
+	t.Run("a raw client call ends up in history", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("Paris"), textResponse("About 2 million."))
+		myAgent := New(client, "test-model")
+
+		req := llm.ChatRequest{Model: "test-model", Messages: []llm.Message{llm.NewUserMessage("capital of France?")}}
+		resp, err := client.CreateChat(context.Background(), req)
+		if err != nil {
+			t.Fatalf("CreateChat failed: %v", err)
+		}
+
+		myAgent.History = append(myAgent.History, req.Messages...)
+		if err := myAgent.AppendResponse(resp); err != nil {
+			t.Fatalf("AppendResponse failed: %v", err)
+		}
+
+		last := myAgent.History[len(myAgent.History)-1]
+		if !last.IsAssistant() || last.Content != "Paris" {
+			t.Errorf("got last message %+v want the assistant answer", last)
+		}
+
+		// the agent carries on from there
+		myAgent.Run(context.Background(), "how many people live there?")
+		sent := api.requests[1].Messages
+		if len(sent) != 3 || sent[1].Content != "Paris" {
+			t.Errorf("got %+v want the appended answer sent along", sent)
+		}
+	})
+
+	t.Run("tool calls are left pending", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "test-model")
+
+		resp := toolCallResponse(toolCall("call_1", "get_weather", `{}`))
+		if err := myAgent.AppendResponse(&resp); err != nil {
+			t.Fatalf("AppendResponse failed: %v", err)
+		}
+		if pending := myAgent.PendingToolCalls(); len(pending) != 1 || pending[0].ID != "call_1" {
+			t.Errorf("got pending %+v want call_1", pending)
+		}
+	})
+
+	t.Run("only assistant messages", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "test-model")
+
+		for _, resp := range []*llm.ChatResponse{
+			nil,
+			{},
+			{Choices: []llm.Choice{{Message: llm.NewUserMessage("hi")}}},
+		} {
+			if err := myAgent.AppendResponse(resp); err == nil {
+				t.Errorf("expected an error for %+v", resp)
+			}
+		}
+		if len(myAgent.History) != 0 {
+			t.Errorf("got history %+v want it untouched", myAgent.History)
+		}
+	})
+}
+
+// assertValidToolChain checks every tool result answers an earlier call and every call gets exactly one result
+func assertValidToolChain(t *testing.T, history []llm.Message) {
+	t.Helper()
+
+	pending := make(map[string]bool)
+	for i, msg := range history {
+		for _, call := range msg.ToolCalls {
+			if _, seen := pending[call.ID]; seen {
+				t.Errorf("message %d: duplicate call id %q", i, call.ID)
+			}
+			pending[call.ID] = true
+		}
+		if msg.Role == "tool" {
+			if !pending[msg.ToolCallID] {
+				t.Errorf("message %d: result for unknown or already answered call %q", i, msg.ToolCallID)
+			}
+			pending[msg.ToolCallID] = false
+		}
+	}
+	for id, open := range pending {
+		if open {
+			t.Errorf("call %q never got a result", id)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package agent
+
+import "my_agent/llm"
+
+// WithMaxHistoryMessages keeps only the last n non-system messages, checked every time a user message comes in
+// cheaper than counting tokens and good enough for plenty of chat bots, the system prompt always stays
+func WithMaxHistoryMessages(n int) Option {
+	return func(a *Agent) {
+		a.MaxHistoryMessages = n
+	}
+}
+
+// WithTransforms hands context management to OpenRouter, WithTransforms("middle-out") has it compress
+// the middle of a prompt that no longer fits instead of the request failing
+// an alternative to trimming locally with WithMaxHistoryTokens, the provider decides what gets lost though
+func WithTransforms(transforms ...string) Option {
+	return func(a *Agent) {
+		a.Transforms = append(a.Transforms, transforms...)
+	}
+}
+
+// WithStateless makes every Run independent, only the system prompt and the new user message are sent
+// and History never grows, for one shot jobs like classification on a long lived agent
+func WithStateless() Option {
+	return func(a *Agent) {
+		a.Stateless = true
+	}
+}
+
+// WithMaxHistoryTokens keeps the history under roughly n tokens, oldest messages go first
+// the count is an estimate (about 4 characters a token), not the model's real tokenizer
+func WithMaxHistoryTokens(n int) Option {
+	return func(a *Agent) {
+		a.MaxHistoryTokens = n
+	}
+}
+
+// WithModelContextBudget sizes the token budget from the model itself: its context length from /models
+// minus room for MaxTokens of output, so there is no limit to guess
+// the client only knows the length after ListModels or SetModelInfo, until then fallbackContextLength is used
+// an explicit WithMaxHistoryTokens still wins
+func WithModelContextBudget() Option {
+	return func(a *Agent) {
+		a.ModelContextBudget = true
+	}
+}
+
+// AddMessage appends msg to History, safe to call while another goroutine runs the agent
+// it waits for a Run in progress to finish first, so it never lands in the middle of a tool chain
+func (a *Agent) AddMessage(msg llm.Message) {
+	a.lockRun()
+	defer a.unlockRun()
+	a.appendHistory(msg)
+}
+
+// Reset starts the conversation over, only the system prompt is kept
+// pending manual tool calls are dropped too, the usage behind TotalCost is kept
+func (a *Agent) Reset() {
+	a.lockRun()
+	defer a.unlockRun()
+
+	a.History = make([]llm.Message, 0)
+	if a.SystemPrompt != "" {
+		a.History = append(a.History, llm.NewSystemMessage(a.SystemPrompt))
+	}
+	a.pending = nil
+	a.lastLogprobs = nil
+}
+
+const (
+	// what we assume for a model we know nothing about, small enough for almost anything still in use
+	fallbackContextLength = 8192
+	// room kept for the reply when MaxTokens is not set
+	defaultOutputReserve = 1024
+)
+
+// tokenBudget is how many tokens the history may use, 0 means no limit
+func (a *Agent) tokenBudget() int {
+	if a.MaxHistoryTokens > 0 {
+		return a.MaxHistoryTokens
+	}
+	if !a.ModelContextBudget {
+		return 0
+	}
+
+	length := fallbackContextLength
+	if a.client != nil {
+		if known, ok := a.client.ContextLength(a.model()); ok {
+			length = known
+		}
+	}
+
+	reserve := a.callOptions(a.model()).MaxTokens
+	if reserve <= 0 {
+		reserve = defaultOutputReserve
+	}
+	return max(length-reserve, 0)
+}
+
+// estimateTokens is a rough count for one message, 4 characters a token plus a little per message overhead
+func estimateTokens(msg llm.Message) int {
+	chars := len(msg.Content)
+	for _, call := range msg.ToolCalls {
+		chars += len(call.Function.Name) + len(call.Function.Arguments)
+	}
+	return chars/4 + 4
+}
+
+// trimHistoryTokens drops the oldest messages until the history fits tokenBudget
+// the system prompt and the newest message always stay, and like trimHistory
+// a cut never leaves tool results behind without their call
+func (a *Agent) trimHistoryTokens() {
+	budget := a.tokenBudget()
+	if budget <= 0 {
+		return
+	}
+
+	var system []llm.Message
+	rest := a.History
+	if len(rest) > 0 && rest[0].IsSystem() {
+		system = rest[:1]
+		rest = rest[1:]
+	}
+
+	total := 0
+	for _, msg := range a.History {
+		total += estimateTokens(msg)
+	}
+	if total <= budget {
+		return
+	}
+
+	for len(rest) > 1 && total > budget {
+		total -= estimateTokens(rest[0])
+		rest = rest[1:]
+		for len(rest) > 1 && rest[0].IsToolResult() {
+			total -= estimateTokens(rest[0])
+			rest = rest[1:]
+		}
+	}
+
+	a.History = append(append([]llm.Message(nil), system...), rest...)
+}
+
+// statelessHistory swaps History for just the system prompt and returns how to put the real one back
+// the run in between can append as usual (tool calls still need their results), it is all thrown away
+func (a *Agent) statelessHistory() (restore func()) {
+	saved := a.History
+
+	a.History = nil
+	if len(saved) > 0 && saved[0].IsSystem() {
+		a.History = []llm.Message{saved[0]}
+	}
+	return func() { a.History = saved }
+}
+
+// trimHistory drops the oldest messages past MaxHistoryMessages
+// a cut that would leave tool results without the assistant message that asked for them moves forward
+// past those results, providers reject a tool message whose call is gone
+func (a *Agent) trimHistory() {
+	if a.MaxHistoryMessages <= 0 {
+		return
+	}
+
+	var system []llm.Message
+	rest := a.History
+	if len(rest) > 0 && rest[0].IsSystem() {
+		system = rest[:1]
+		rest = rest[1:]
+	}
+
+	if len(rest) <= a.MaxHistoryMessages {
+		return
+	}
+
+	rest = rest[len(rest)-a.MaxHistoryMessages:]
+	for len(rest) > 0 && rest[0].IsToolResult() {
+		rest = rest[1:]
+	}
+
+	a.History = append(append([]llm.Message(nil), system...), rest...)
+}
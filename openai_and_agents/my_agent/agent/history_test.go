@@ -0,0 +1,264 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+	"my_agent/tools"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAgent_WithMaxHistoryMessages(t *testing.T) {
+	t.Run("oldest messages are dropped", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("three"))
+		myAgent := New(client, "test-model", WithSystemPrompts("Be brief."), WithMaxHistoryMessages(3))
+		myAgent.History = append(myAgent.History,
+			llm.NewUserMessage("one"),
+			llm.NewAssistantMessage("1"),
+			llm.NewUserMessage("two"),
+			llm.NewAssistantMessage("2"),
+		)
+
+		if _, err := myAgent.Run(context.Background(), "three?"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		// system prompt plus the last three before the request went out
+		sent := api.requests[0].Messages
+		want := []string{"Be brief.", "two", "2", "three?"}
+		if len(sent) != len(want) {
+			t.Fatalf("got %d messages sent want %d: %+v", len(sent), len(want), sent)
+		}
+		for i, content := range want {
+			if sent[i].Content != content {
+				t.Errorf("message %d: got %q want %q", i, sent[i].Content, content)
+			}
+		}
+	})
+
+	t.Run("tool results are never orphaned", func(t *testing.T) {
+		myAgent := New(nil, "test-model", WithMaxHistoryMessages(3))
+		myAgent.History = append(myAgent.History,
+			llm.NewUserMessage("weather in Paris and Oslo?"),
+			llm.NewToolCallMessage([]llm.ToolCall{
+				toolCall("call_1", "get_weather", `{"city":"Paris"}`),
+				toolCall("call_2", "get_weather", `{"city":"Oslo"}`),
+			}),
+			llm.NewToolResult("call_1", "sunny"),
+			llm.NewToolResult("call_2", "snow"),
+			llm.NewAssistantMessage("Sunny in Paris, snow in Oslo."),
+			llm.NewUserMessage("thanks"),
+		)
+
+		myAgent.trimHistory()
+
+		// the plain cut would start at the call_2 result, so that goes as well
+		if len(myAgent.History) != 2 {
+			t.Fatalf("got %d messages want 2: %+v", len(myAgent.History), myAgent.History)
+		}
+		if myAgent.History[0].Role == "tool" {
+			t.Errorf("history starts with a tool result: %+v", myAgent.History[0])
+		}
+		assertValidToolChain(t, myAgent.History)
+	})
+}
+
+func TestAgent_WithStateless(t *testing.T) {
+	client, api := newMockClient(t, textResponse("positive"), textResponse("negative"), textResponse("neutral"))
+	myAgent := New(client, "test-model", WithSystemPrompts("Classify the sentiment."), WithStateless())
+
+	inputs := []string{"great movie", "awful movie", "a movie"}
+	for _, input := range inputs {
+		if _, err := myAgent.Run(context.Background(), input); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(myAgent.History) != 1 {
+			t.Fatalf("got %d messages in history want just the system prompt", len(myAgent.History))
+		}
+	}
+
+	// every request is the system prompt and that run's input, nothing from before
+	for i, req := range api.requests {
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Content != inputs[i] {
+			t.Errorf("request %d: got %+v", i, req.Messages)
+		}
+	}
+}
+
+func TestAgent_TokenBudget(t *testing.T) {
+	client, err := llm.NewClient("test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.SetModelInfo(llm.ModelInfo{ID: "big-model", ContextLength: 128000})
+
+	cases := []struct {
+		name  string
+		model string
+		opts  []Option
+		want  int
+	}{
+		{"off by default", "big-model", nil, 0},
+		{"model context minus max tokens", "big-model", []Option{WithModelContextBudget(), WithMaxTokens(4000)}, 124000},
+		{"default reserve without max tokens", "big-model", []Option{WithModelContextBudget()}, 128000 - defaultOutputReserve},
+		{"unknown model falls back", "mystery-model", []Option{WithModelContextBudget(), WithMaxTokens(1000)}, fallbackContextLength - 1000},
+		{"explicit limit wins", "big-model", []Option{WithModelContextBudget(), WithMaxHistoryTokens(500)}, 500},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := New(client, tc.model, tc.opts...).tokenBudget(); got != tc.want {
+				t.Errorf("got budget %d want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAgent_TrimHistoryTokens(t *testing.T) {
+	client, api := newMockClient(t, textResponse("ok"))
+	client.SetModelInfo(llm.ModelInfo{ID: "tiny-model", ContextLength: 200})
+	// 200 tokens of context minus 100 for the reply leaves 100 for the history
+	myAgent := New(client, "tiny-model", WithSystemPrompts("Be brief."), WithModelContextBudget(), WithMaxTokens(100))
+
+	long := strings.Repeat("word ", 60) // about 75 tokens each
+	myAgent.History = append(myAgent.History,
+		llm.NewUserMessage(long),
+		llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_1", "lookup", `{}`)}),
+		llm.NewToolResult("call_1", long),
+		llm.NewAssistantMessage("done"),
+	)
+
+	if _, err := myAgent.Run(context.Background(), "and now?"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	sent := api.requests[0].Messages
+	if sent[0].Content != "Be brief." || sent[len(sent)-1].Content != "and now?" {
+		t.Errorf("got %+v want the system prompt and the new message kept", sent)
+	}
+	total := 0
+	for _, msg := range sent {
+		total += estimateTokens(msg)
+	}
+	if total > 100 {
+		t.Errorf("got about %d tokens sent want at most 100", total)
+	}
+	assertValidToolChain(t, sent)
+}
+
+// run with -race, without the lock the two runs write History at the same time
+func TestAgent_ConcurrentRuns(t *testing.T) {
+	client, _ := newMockClient(t, textResponse("one"), textResponse("two"))
+	myAgent := New(client, "test-model", WithSystemPrompts("be brief"))
+
+	var wg sync.WaitGroup
+	for _, msg := range []string{"first", "second"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := myAgent.Run(context.Background(), msg); err != nil {
+				t.Errorf("Run failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the runs took turns, so every user message is directly followed by its answer
+	history := myAgent.History
+	if len(history) != 5 {
+		t.Fatalf("got %d messages want 5: %+v", len(history), history)
+	}
+	for i := 1; i < len(history); i += 2 {
+		if !history[i].IsUser() || !history[i+1].IsAssistant() {
+			t.Errorf("turns got interleaved: %+v", history)
+		}
+	}
+}
+
+func TestAgent_ReadersDuringRun(t *testing.T) {
+	// each of these used to deadlock, the run held a.mu while it called out
+	t.Run("from the events loop and from a tool", func(t *testing.T) {
+		client, _ := newMockClient(t,
+			toolCallResponse(toolCall("call_1", "peek", `{}`)),
+			textResponse("done"),
+		)
+		registry := tools.NewRegistry()
+		var myAgent *Agent
+		registry.Register("peek", "Look at the transcript", func() (string, error) {
+			return myAgent.Transcript(TranscriptMarkdown)
+		})
+		myAgent = New(client, "test-model", WithTools(registry))
+
+		finished := make(chan error, 1)
+		go func() {
+			events, errs := myAgent.RunEvents(context.Background(), "hi")
+			for range events {
+				myAgent.Transcript(TranscriptMarkdown)
+				myAgent.PendingToolCalls()
+				myAgent.LastLogprobs()
+			}
+			finished <- <-errs
+		}()
+
+		select {
+		case err := <-finished:
+			if err != nil {
+				t.Fatalf("RunEvents failed: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("the run deadlocked on a reader")
+		}
+	})
+
+	t.Run("while a stream is not drained yet", func(t *testing.T) {
+		myAgent := New(sseServer(t, true, "Hello", " world"), "test-model")
+
+		tokens, errs := myAgent.RunStream(context.Background(), "hi")
+		<-tokens
+
+		read := make(chan struct{})
+		go func() {
+			myAgent.Transcript(TranscriptMarkdown)
+			close(read)
+		}()
+		select {
+		case <-read:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Transcript waited for the stream to be drained")
+		}
+
+		for range tokens {
+		}
+		if err := <-errs; err != nil {
+			t.Fatalf("RunStream failed: %v", err)
+		}
+	})
+}
+
+func TestAgent_AddMessageAndReset(t *testing.T) {
+	client, _ := newMockClient(t)
+	myAgent := New(client, "test-model", WithSystemPrompts("be brief"))
+
+	myAgent.AddMessage(llm.NewUserMessage("hi"))
+	myAgent.AddMessage(llm.NewAssistantMessage("hello"))
+	if len(myAgent.History) != 3 {
+		t.Fatalf("got %d messages want 3", len(myAgent.History))
+	}
+
+	myAgent.Reset()
+	if len(myAgent.History) != 1 || myAgent.History[0].Content != "be brief" {
+		t.Errorf("got %+v want only the system prompt", myAgent.History)
+	}
+}
+
+func TestAgent_WithTransforms(t *testing.T) {
+	client, api := newMockClient(t, textResponse("ok"))
+	myAgent := New(client, "test-model", WithTransforms("middle-out"))
+
+	myAgent.Run(context.Background(), "hi")
+
+	if got := api.requests[0].Transforms; len(got) != 1 || got[0] != "middle-out" {
+		t.Errorf("got transforms %v want [middle-out]", got)
+	}
+}
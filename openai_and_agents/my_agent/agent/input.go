@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInputTooLarge is returned before any api call when the user message is over WithMaxInputBytes
+	ErrInputTooLarge = errors.New("input too large")
+	// ErrEmptyInput is returned for a blank user message when there is nothing pending for the model to answer
+	ErrEmptyInput = errors.New("empty input")
+)
+
+// WithMaxInputBytes rejects Run calls whose user message is bigger than n bytes
+// a 10MB paste would blow the context window (and our bill) long before the provider complains
+func WithMaxInputBytes(n int) Option {
+	return func(a *Agent) {
+		a.MaxInputBytes = n
+	}
+}
+
+// checkInput runs before the user message goes into history
+// a blank message is only fine when continuing a turn, ie the last message is a user message or a tool result
+// the model still has something to answer then (that is how a tool loop gets resumed)
+func (a *Agent) checkInput(usrMsg string) error {
+	if a.MaxInputBytes > 0 && len(usrMsg) > a.MaxInputBytes {
+		return fmt.Errorf("%w: %d bytes, the limit is %d", ErrInputTooLarge, len(usrMsg), a.MaxInputBytes)
+	}
+
+	if strings.TrimSpace(usrMsg) != "" {
+		return nil
+	}
+
+	if n := len(a.History); n > 0 {
+		last := a.History[n-1]
+		if last.IsUser() || last.IsToolResult() {
+			return nil
+		}
+	}
+	return ErrEmptyInput
+}
@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAgent_InputLimits(t *testing.T) {
+	t.Run("oversized input never reaches the api", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("should not happen"))
+		myAgent := New(client, "test-model", WithMaxInputBytes(1024))
+
+		_, err := myAgent.Run(context.Background(), strings.Repeat("a", 2048))
+
+		if !errors.Is(err, ErrInputTooLarge) {
+			t.Errorf("got %v want ErrInputTooLarge", err)
+		}
+		if len(api.requests) != 0 {
+			t.Errorf("got %d requests want 0", len(api.requests))
+		}
+		if len(myAgent.History) != 0 {
+			t.Errorf("got %d messages in history want 0", len(myAgent.History))
+		}
+	})
+
+	t.Run("input under the limit goes through", func(t *testing.T) {
+		client, _ := newMockClient(t, textResponse("hi"))
+		myAgent := New(client, "test-model", WithMaxInputBytes(1024))
+
+		if _, err := myAgent.Run(context.Background(), "hello"); err != nil {
+			t.Errorf("got %v want nil", err)
+		}
+	})
+
+	t.Run("whitespace only input with nothing pending", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("should not happen"))
+		myAgent := New(client, "test-model", WithSystemPrompts("be nice"))
+
+		if _, err := myAgent.Run(context.Background(), "  \n\t"); !errors.Is(err, ErrEmptyInput) {
+			t.Errorf("got %v want ErrEmptyInput", err)
+		}
+		if len(api.requests) != 0 {
+			t.Errorf("got %d requests want 0", len(api.requests))
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"my_agent/llm"
+	"slices"
+)
+
+// ErrWaitingForTools is what Run returns when the model called a manual tool
+// the calls are in PendingToolCalls, answer each with ResolveToolCall and call Run again with an empty message
+var ErrWaitingForTools = errors.New("waiting for manual tool results")
+
+// WithManualTools marks tools whose result comes from outside the agent, a human approving a payment for example
+// they are still offered to the model but never executed, Run pauses with ErrWaitingForTools instead
+// other tools asked for in the same turn still run right away
+// the pause lives in History, so this doesn't combine with WithStateless
+func WithManualTools(names ...string) Option {
+	return func(a *Agent) {
+		if a.manualTools == nil {
+			a.manualTools = make(map[string]bool)
+		}
+		for _, name := range names {
+			a.manualTools[name] = true
+		}
+	}
+}
+
+// PendingToolCalls are the manual tool calls still waiting for ResolveToolCall, in the order the model made them
+func (a *Agent) PendingToolCalls() []llm.ToolCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.pending)
+}
+
+// ResolveToolCall supplies the result of a pending manual tool call, it goes into history like any tool result
+// once nothing is pending Run("") picks the conversation up where it paused
+func (a *Agent) ResolveToolCall(id, result string) error {
+	a.lockRun()
+	defer a.unlockRun()
+
+	i := slices.IndexFunc(a.pending, func(call llm.ToolCall) bool { return call.ID == id })
+	if i < 0 {
+		return fmt.Errorf("no pending tool call with id %q", id)
+	}
+
+	a.pending = slices.Delete(a.pending, i, i+1)
+	a.appendHistory(llm.NewToolResult(id, result))
+	return nil
+}
+
+// splitManual separates the calls we run ourselves from the ones left for ResolveToolCall
+func (a *Agent) splitManual(calls []llm.ToolCall) (auto, manual []llm.ToolCall) {
+	for _, call := range calls {
+		if a.manualTools[call.Function.Name] {
+			manual = append(manual, call)
+		} else {
+			auto = append(auto, call)
+		}
+	}
+	return auto, manual
+}
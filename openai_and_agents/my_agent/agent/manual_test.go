@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"my_agent/tools"
+	"testing"
+)
+
+func TestAgent_ManualTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("approve_refund", "Ask a human to approve the refund", func() string { return "never runs" })
+	registry.Register("get_order", "", func() string { return "order 42, 30 EUR" })
+
+	client, api := newMockClient(t,
+		toolCallResponse(toolCall("call_1", "get_order", `{}`), toolCall("call_2", "approve_refund", `{}`)),
+		textResponse("Your refund is on its way."),
+	)
+	myAgent := New(client, "test-model", WithTools(registry), WithManualTools("approve_refund"))
+
+	_, err := myAgent.Run(context.Background(), "refund order 42")
+	if !errors.Is(err, ErrWaitingForTools) {
+		t.Fatalf("got %v want ErrWaitingForTools", err)
+	}
+
+	pending := myAgent.PendingToolCalls()
+	if len(pending) != 1 || pending[0].ID != "call_2" {
+		t.Fatalf("got pending %+v want only the approve_refund call", pending)
+	}
+
+	// resuming before the human answered must not send a broken history
+	if _, err := myAgent.Run(context.Background(), ""); !errors.Is(err, ErrWaitingForTools) {
+		t.Errorf("got %v want ErrWaitingForTools while calls are pending", err)
+	}
+	if len(api.requests) != 1 {
+		t.Fatalf("got %d requests want 1", len(api.requests))
+	}
+
+	if err := myAgent.ResolveToolCall("call_9", "approved"); err == nil {
+		t.Error("expected an error for an unknown call id")
+	}
+	if err := myAgent.ResolveToolCall("call_2", "approved by Sam"); err != nil {
+		t.Fatalf("ResolveToolCall failed: %v", err)
+	}
+
+	reply, err := myAgent.Run(context.Background(), "")
+	if err != nil {
+		t.Fatalf("resumed Run failed: %v", err)
+	}
+	if reply != "Your refund is on its way." {
+		t.Errorf("got %q", reply)
+	}
+
+	// the resumed request carries both results, the automatic one and the human one
+	sent := api.requests[1].Messages
+	assertValidToolChain(t, sent)
+	last := sent[len(sent)-1]
+	if last.ToolCallID != "call_2" || last.Content != "approved by Sam" {
+		t.Errorf("got last message %+v want the resolved result", last)
+	}
+}
@@ -0,0 +1,139 @@
+package agent
+
+import "my_agent/llm"
+
+// CallOptions are the sampling knobs that go into every request
+// zero means "not set" which is also what the request does with them (omitempty), so unset fields fall through
+// to the model defaults and then to the agent's built in defaults
+type CallOptions struct {
+	Temperature float64
+	TopP        float64
+	MaxTokens   int
+	Seed        int
+
+	// Logprobs asks for token level log probabilities, TopLogprobs for that many alternatives per token
+	Logprobs    bool
+	TopLogprobs int
+
+	// NoTools keeps the tool definitions out of the request, for models without function calling
+	NoTools bool
+
+	// TemperatureSet makes a 0 Temperature mean greedy decoding instead of "not set"
+	// WithTemperature sets it, a model default wanting 0 says {TemperatureSet: true}
+	TemperatureSet bool
+}
+
+// what the agent uses when neither the caller nor the model defaults say anything
+var baseCallOptions = CallOptions{
+	Temperature: 0.7,
+}
+
+// WithTemperature sets the temperature explicitly, it beats any model default
+// 0 is honored too and sent as is, pair it with WithSeed for the most repeatable output
+func WithTemperature(t float64) Option {
+	return func(a *Agent) {
+		a.Options.Temperature = t
+		a.Options.TemperatureSet = true
+	}
+}
+
+// WithTemperatureSchedule picks the temperature per Run from how many runs came before (0 for the first)
+// e.g. explore first and exploit later: func(turn int) float64 { return max(1.0-0.2*float64(turn), 0.1) }
+// it beats WithTemperature and the model defaults, without it the temperature stays fixed
+func WithTemperatureSchedule(schedule func(turn int) float64) Option {
+	return func(a *Agent) {
+		a.TemperatureSchedule = schedule
+	}
+}
+
+// WithSeed asks the provider for reproducible sampling, 0 leaves the seed out of the request
+// determinism is best effort on the provider side: not every model or route honors a seed,
+// and a changed system_fingerprint on the response means the backend changed and outputs may differ anyway
+// good enough for eval runs, don't build anything that breaks when two answers differ
+func WithSeed(seed int) Option {
+	return func(a *Agent) {
+		a.Options.Seed = seed
+	}
+}
+
+// WithLogprobs turns on token log probabilities with n alternatives per token (0 for just the chosen token)
+// read them back with LastLogprobs after Run
+func WithLogprobs(n int) Option {
+	return func(a *Agent) {
+		a.Options.Logprobs = true
+		a.Options.TopLogprobs = n
+	}
+}
+
+func WithMaxTokens(n int) Option {
+	return func(a *Agent) {
+		a.Options.MaxTokens = n
+	}
+}
+
+// WithModelDefaults registers per model defaults, consulted for whatever model the agent is using
+// so switching models does not mean retuning every parameter by hand
+//
+//	agent.WithModelDefaults(map[string]agent.CallOptions{
+//		"openai/o3-mini":        {Temperature: 1},
+//		"some/tiny-local-model": {Temperature: 0.2, NoTools: true},
+//		"some/greedy-model":     {TemperatureSet: true}, // an explicit 0
+//	})
+func WithModelDefaults(defaults map[string]CallOptions) Option {
+	return func(a *Agent) {
+		if a.ModelDefaults == nil {
+			a.ModelDefaults = make(map[string]CallOptions)
+		}
+		for model, opts := range defaults {
+			a.ModelDefaults[model] = opts
+		}
+	}
+}
+
+// orDefaults fills every unset field of o from d
+func (o CallOptions) orDefaults(d CallOptions) CallOptions {
+	if o.Temperature == 0 && !o.TemperatureSet {
+		o.Temperature = d.Temperature
+		o.TemperatureSet = d.TemperatureSet
+	}
+	if o.TopP == 0 {
+		o.TopP = d.TopP
+	}
+	if o.MaxTokens == 0 {
+		o.MaxTokens = d.MaxTokens
+	}
+	if o.Seed == 0 {
+		o.Seed = d.Seed
+	}
+	if o.TopLogprobs == 0 {
+		o.TopLogprobs = d.TopLogprobs
+	}
+	o.Logprobs = o.Logprobs || d.Logprobs
+	o.NoTools = o.NoTools || d.NoTools
+	return o
+}
+
+// callOptions resolves what actually gets sent for model: explicit options, then model defaults, then ours
+func (a *Agent) callOptions(model string) CallOptions {
+	opts := a.Options
+	if a.TemperatureSchedule != nil {
+		opts.Temperature = a.TemperatureSchedule(a.turns)
+		opts.TemperatureSet = true
+	}
+	return opts.orDefaults(a.ModelDefaults[model]).orDefaults(baseCallOptions)
+}
+
+func (o CallOptions) apply(req *llm.ChatRequest) {
+	req.Temperature = o.Temperature
+	req.TemperatureSet = o.TemperatureSet
+	req.TopP = o.TopP
+	req.MaxTokens = o.MaxTokens
+	req.Seed = o.Seed
+	req.Logprobs = o.Logprobs
+	if o.Logprobs {
+		req.TopLogprobs = o.TopLogprobs
+	}
+	if o.NoTools {
+		req.Tools = nil
+	}
+}
@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+	"my_agent/tools"
+	"testing"
+)
+
+func TestAgent_ModelDefaults(t *testing.T) {
+	defaults := map[string]CallOptions{
+		"cold-model":     {Temperature: 0.1, MaxTokens: 256},
+		"no-tools-model": {NoTools: true},
+	}
+
+	t.Run("model defaults apply when nothing is set", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "cold-model", WithModelDefaults(defaults))
+
+		if _, err := myAgent.Run(context.Background(), "hi"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		req := api.requests[0]
+		if req.Temperature != 0.1 || req.MaxTokens != 256 {
+			t.Errorf("got temperature %v max tokens %d, want 0.1 and 256", req.Temperature, req.MaxTokens)
+		}
+	})
+
+	t.Run("explicit options beat model defaults", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "cold-model", WithModelDefaults(defaults), WithTemperature(0.9))
+
+		myAgent.Run(context.Background(), "hi")
+
+		req := api.requests[0]
+		if req.Temperature != 0.9 || req.MaxTokens != 256 {
+			t.Errorf("got temperature %v max tokens %d, want 0.9 and 256", req.Temperature, req.MaxTokens)
+		}
+	})
+
+	t.Run("unknown models keep the built in default", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "other-model", WithModelDefaults(defaults))
+
+		myAgent.Run(context.Background(), "hi")
+
+		if got := api.requests[0].Temperature; got != 0.7 {
+			t.Errorf("got temperature %v want 0.7", got)
+		}
+	})
+
+	t.Run("an explicit zero temperature is sent", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "cold-model", WithModelDefaults(defaults), WithTemperature(0), WithSeed(42))
+
+		// the mock decodes into a ChatRequest where 0 and missing look the same, so check before the wire
+		if req := myAgent.buildRequest(); req.Temperature != 0 || !req.TemperatureSet {
+			t.Errorf("got temperature %v set %v want an explicit 0", req.Temperature, req.TemperatureSet)
+		}
+	})
+
+	t.Run("a model default can ask for zero", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "greedy-model", WithModelDefaults(map[string]CallOptions{
+			"greedy-model": {TemperatureSet: true},
+		}))
+
+		if req := myAgent.buildRequest(); req.Temperature != 0 || !req.TemperatureSet {
+			t.Errorf("got temperature %v set %v want an explicit 0, not the built in 0.7", req.Temperature, req.TemperatureSet)
+		}
+	})
+
+	t.Run("models without tool support get no tools", func(t *testing.T) {
+		registry := tools.NewRegistry()
+		registry.Register("get_current_time", "", func() string { return "noon" })
+
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "no-tools-model", WithModelDefaults(defaults), WithTools(registry))
+
+		myAgent.Run(context.Background(), "hi")
+
+		if got := len(api.requests[0].Tools); got != 0 {
+			t.Errorf("got %d tools want 0", got)
+		}
+	})
+}
+
+func TestAgent_WithSeed(t *testing.T) {
+	t.Run("seed goes into the request", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "test-model", WithSeed(42))
+
+		myAgent.Run(context.Background(), "hi")
+
+		if got := api.requests[0].Seed; got != 42 {
+			t.Errorf("got seed %d want 42", got)
+		}
+	})
+
+	t.Run("no seed is omitted", func(t *testing.T) {
+		myAgent := New(nil, "test-model")
+
+		if got := myAgent.buildRequest().Seed; got != 0 {
+			t.Errorf("got seed %d want 0 (unset)", got)
+		}
+	})
+}
+
+func TestAgent_WithLogprobs(t *testing.T) {
+	resp := textResponse("positive")
+	resp.Choices[0].Logprobs = &llm.Logprobs{Content: []llm.TokenLogprob{{Token: "positive", Logprob: -0.01}}}
+
+	client, api := newMockClient(t, resp)
+	myAgent := New(client, "test-model", WithLogprobs(3))
+
+	if _, err := myAgent.Run(context.Background(), "classify: great movie"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	req := api.requests[0]
+	if !req.Logprobs || req.TopLogprobs != 3 {
+		t.Errorf("got logprobs %v top %d want true and 3", req.Logprobs, req.TopLogprobs)
+	}
+
+	got := myAgent.LastLogprobs()
+	if got == nil || got.Content[0].Token != "positive" {
+		t.Errorf("got %+v want the logprobs of the answer", got)
+	}
+}
+
+func TestAgent_TemperatureSchedule(t *testing.T) {
+	client, api := newMockClient(t, textResponse("one"), textResponse("two"), textResponse("three"))
+	myAgent := New(client, "test-model",
+		WithTemperature(0.3), // the schedule wins
+		WithTemperatureSchedule(func(turn int) float64 { return 1.0 - 0.5*float64(turn) }),
+	)
+
+	for range 3 {
+		if _, err := myAgent.Run(context.Background(), "next idea"); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	for i, want := range []float64{1.0, 0.5, 0} {
+		if got := api.requests[i].Temperature; got != want {
+			t.Errorf("run %d: got temperature %v want %v", i, got, want)
+		}
+	}
+}
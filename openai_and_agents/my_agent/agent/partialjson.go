@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONAccumulator collects a json object streamed token by token and parses it while it is still incomplete
+// after every token it closes whatever strings, objects and arrays are still open and hands the result
+// to OnPartial, so a form can fill in field by field while the model is still writing
+// a key without its value yet is left out, strings show up as far as they got and numbers can still grow
+//
+// the scan is incremental, a token only costs a look at its own bytes plus decoding the object so far
+type JSONAccumulator struct {
+	// OnPartial gets the best effort parse, only when it changed since the last call
+	OnPartial func(partial map[string]any)
+
+	buf  strings.Builder
+	last string
+
+	// where the object starts in buf, -1 until its '{' shows up
+	start int
+	// everything of buf up to here (from start) is already in scan
+	scanned int
+	scan    jsonScan
+}
+
+func NewJSONAccumulator(onPartial func(partial map[string]any)) *JSONAccumulator {
+	return &JSONAccumulator{OnPartial: onPartial, start: -1}
+}
+
+// Write adds a token and reports the new partial parse, if there is one
+func (j *JSONAccumulator) Write(token string) {
+	j.buf.WriteString(token)
+
+	completed, partial, ok := j.parse()
+	if !ok || completed == j.last {
+		return
+	}
+	j.last = completed
+	if j.OnPartial != nil {
+		j.OnPartial(partial)
+	}
+}
+
+// parse scans the bytes added since the last call and parses the object so far
+// first closed right where the text stops, then cut back to the last complete value
+func (j *JSONAccumulator) parse() (string, map[string]any, bool) {
+	text := j.buf.String()
+	if j.start < 0 {
+		j.start = strings.IndexByte(text[j.scanned:], '{')
+		if j.start < 0 {
+			j.scanned = len(text)
+			return "", nil, false
+		}
+		j.start += j.scanned
+		j.scanned = j.start
+	}
+	text = text[j.start:]
+
+	// once the object is complete whatever follows (a closing ``` fence) is not ours
+	if j.scan.end == 0 {
+		j.scan.feed(text, j.scanned-j.start)
+		j.scanned = j.start + len(text)
+	}
+	if j.scan.end > 0 {
+		text = text[:j.scan.end]
+	}
+
+	if completed, partial, ok := decodePartial(j.scan.close(text)); ok {
+		return completed, partial, true
+	}
+	if j.scan.cut < 0 {
+		return "", nil, false
+	}
+	return decodePartial(closeAt(text[:j.scan.cut], j.scan.cutOpen))
+}
+
+func decodePartial(completed string) (string, map[string]any, bool) {
+	var partial map[string]any
+	if json.Unmarshal([]byte(completed), &partial) != nil {
+		return "", nil, false
+	}
+	return completed, partial, true
+}
+
+// String is everything written so far
+func (j *JSONAccumulator) String() string {
+	return j.buf.String()
+}
+
+// Finish decodes the complete reply into v, strictly this time
+// a ```json fence around the object is tolerated, models like adding one
+func (j *JSONAccumulator) Finish(v any) error {
+	text := strings.TrimSpace(j.buf.String())
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		return fmt.Errorf("unable to decode the streamed json: %w", err)
+	}
+	return nil
+}
+
+// RunStreamJSON streams a reply the model was asked to give as a json object
+// onPartial sees the object grow as tokens arrive, v gets the complete one at the end
+// the prompt is what asks for json, the system prompt is a good place to describe the fields
+func (a *Agent) RunStreamJSON(ctx context.Context, usrMsg string, v any, onPartial func(partial map[string]any)) error {
+	acc := NewJSONAccumulator(onPartial)
+
+	tokens, errs := a.RunStream(ctx, usrMsg)
+	for token := range tokens {
+		acc.Write(token)
+	}
+	if err := <-errs; err != nil {
+		return err
+	}
+	return acc.Finish(v)
+}
+
+// jsonScan is what a pass over (possibly cut off) json tells us, fed a piece at a time
+type jsonScan struct {
+	// closers still owed, innermost last
+	open     []byte
+	inString bool
+	escaped  bool
+	// the last spot the text can be cut and still end on a complete value:
+	// right before a comma or right after an opening bracket, outside of strings
+	// cutOpen is what was open there, cut is -1 until there is one
+	cut     int
+	cutOpen []byte
+	// just past the bracket that closes the outermost value, 0 while it is still open
+	end int
+}
+
+// feed scans text[from:], text[:from] has been fed before
+func (s *jsonScan) feed(text string, from int) {
+	if from == 0 {
+		s.cut = -1
+	}
+	for i := from; i < len(text) && s.end == 0; i++ {
+		c := text[i]
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case s.inString && c == '\\':
+			s.escaped = true
+		case c == '"':
+			s.inString = !s.inString
+		case s.inString:
+		case c == ',':
+			s.markCut(i)
+		case c == '{':
+			s.open = append(s.open, '}')
+			s.markCut(i + 1)
+		case c == '[':
+			s.open = append(s.open, ']')
+			s.markCut(i + 1)
+		case (c == '}' || c == ']') && len(s.open) > 0:
+			s.open = s.open[:len(s.open)-1]
+			if len(s.open) == 0 {
+				s.end = i + 1
+			}
+		}
+	}
+}
+
+func (s *jsonScan) markCut(at int) {
+	s.cut = at
+	s.cutOpen = append(s.cutOpen[:0], s.open...)
+}
+
+// close finishes text (everything fed so far): an open string is closed,
+// a trailing comma or colon is dropped since neither can be completed without inventing a value
+func (s *jsonScan) close(text string) string {
+	if s.inString {
+		// a lone backslash at the very end can't be finished
+		if s.escaped {
+			text = strings.TrimSuffix(text, `\`)
+		}
+		text += `"`
+	} else {
+		text = strings.TrimRight(text, " \t\r\n,:")
+	}
+	return closeAt(text, s.open)
+}
+
+// closeAt closes every open object and array, innermost first
+func closeAt(text string, open []byte) string {
+	var b strings.Builder
+	b.Grow(len(text) + len(open))
+	b.WriteString(text)
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteByte(open[i])
+	}
+	return b.String()
+}
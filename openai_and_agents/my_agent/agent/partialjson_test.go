@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type signupForm struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Tags  []string `json:"tags"`
+}
+
+func TestJSONAccumulator(t *testing.T) {
+	fragments := []string{`{"na`, `me": "Ad`, `a", "em`, `ail": `, `"ada@example.com", "tags": ["math`, `", "poetry"]}`}
+
+	var partials []map[string]any
+	acc := NewJSONAccumulator(func(partial map[string]any) {
+		partials = append(partials, partial)
+	})
+	for _, fragment := range fragments {
+		acc.Write(fragment)
+	}
+
+	want := []map[string]any{
+		{},
+		{"name": "Ad"},
+		{"name": "Ada"},
+		{"name": "Ada", "email": "ada@example.com", "tags": []any{"math"}},
+		{"name": "Ada", "email": "ada@example.com", "tags": []any{"math", "poetry"}},
+	}
+	if !reflect.DeepEqual(partials, want) {
+		t.Errorf("got partials\n%v\nwant\n%v", partials, want)
+	}
+
+	var form signupForm
+	if err := acc.Finish(&form); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	wantForm := signupForm{Name: "Ada", Email: "ada@example.com", Tags: []string{"math", "poetry"}}
+	if !reflect.DeepEqual(form, wantForm) {
+		t.Errorf("got %+v want %+v", form, wantForm)
+	}
+}
+
+func TestJSONAccumulator_ByteByByte(t *testing.T) {
+	// feeding one byte at a time has to end up where a single write of the same prefix does
+	doc := `{"user": {"name": "A\"da", "age": 36, "ok": true}, "tags": ["x", ["y", 2.5]], "note": null}`
+
+	var got map[string]any
+	acc := NewJSONAccumulator(func(partial map[string]any) { got = partial })
+	for i := 1; i <= len(doc); i++ {
+		acc.Write(doc[i-1 : i])
+
+		var want map[string]any
+		NewJSONAccumulator(func(partial map[string]any) { want = partial }).Write(doc[:i])
+		if want != nil && !reflect.DeepEqual(got, want) {
+			t.Fatalf("after %q got %v want %v", doc[:i], got, want)
+		}
+	}
+
+	var full map[string]any
+	if err := acc.Finish(&full); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, full) {
+		t.Errorf("got last partial %v want the full object %v", got, full)
+	}
+}
+
+func TestJSONAccumulator_FinishRejectsIncomplete(t *testing.T) {
+	acc := NewJSONAccumulator(nil)
+	acc.Write(`{"name": "Ad`)
+
+	var form signupForm
+	if err := acc.Finish(&form); err == nil {
+		t.Error("expected an error for json that never got finished")
+	}
+}
+
+func TestAgent_RunStreamJSON(t *testing.T) {
+	myAgent := New(sseServer(t, true, "```json\n", `{"name": "Ada",`, ` "email": "ada@example.com"}`, "\n```"), "test-model")
+
+	var partials int
+	var form signupForm
+	err := myAgent.RunStreamJSON(context.Background(), "sign Ada up", &form, func(map[string]any) { partials++ })
+	if err != nil {
+		t.Fatalf("RunStreamJSON failed: %v", err)
+	}
+
+	if form.Name != "Ada" || form.Email != "ada@example.com" {
+		t.Errorf("got %+v", form)
+	}
+	if partials != 2 {
+		t.Errorf("got %d partial updates want 2", partials)
+	}
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+)
+
+// RunResult is everything a Run learned about its answer, not just the text
+type RunResult struct {
+	// the final answer, what Run returns
+	Content string
+	// why the model stopped on the final answer: "stop", "length", "content_filter" ...
+	FinishReason string
+	// the model that actually answered, with provider fallbacks it can differ from the one asked for
+	Model string
+	// tokens of every model call in this run added up, tool call rounds included
+	Usage llm.Usage
+	// every tool call the model made along the way, in order
+	ToolCalls []llm.ToolCall
+	// the seed the final request was sent with, 0 when there was none
+	Seed int
+	// the backend configuration that produced the answer, empty when the provider doesn't report one
+	SystemFingerprint string
+}
+
+// FingerprintChanged reports whether the backend changed between prev and r although both used the same seed
+// that is the case where the same prompt can stop giving the same answer, worth a warning in an eval
+// false when either run had no seed or no fingerprint, there is nothing to compare then
+func (r *RunResult) FingerprintChanged(prev *RunResult) bool {
+	if r == nil || prev == nil || r.Seed == 0 || r.Seed != prev.Seed {
+		return false
+	}
+	if r.SystemFingerprint == "" || prev.SystemFingerprint == "" {
+		return false
+	}
+	return r.SystemFingerprint != prev.SystemFingerprint
+}
+
+// WithFingerprintWarning calls warn whenever a seeded Run comes back from a different backend
+// than the previous Run with the same seed, see RunResult.FingerprintChanged
+func WithFingerprintWarning(warn func(previous, current string)) Option {
+	return func(a *Agent) {
+		a.onFingerprintChange = warn
+	}
+}
+
+// checkFingerprint compares a finished run with the last one and remembers it for the next
+func (a *Agent) checkFingerprint(result *RunResult) {
+	previous := a.lastResult
+	a.lastResult = result
+	if a.onFingerprintChange != nil && result.FingerprintChanged(previous) {
+		a.unlocked(func() { a.onFingerprintChange(previous.SystemFingerprint, result.SystemFingerprint) })
+	}
+}
+
+// RunResult is Run returning the metadata of the answer as well, for when the text alone isn't enough
+// (was it cut off by max_tokens, which model answered, what did it cost)
+func (a *Agent) RunResult(ctx context.Context, usrMsg string) (*RunResult, error) {
+	return a.runSession(ctx, usrMsg, nil)
+}
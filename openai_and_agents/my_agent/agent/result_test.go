@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+	"my_agent/tools"
+	"testing"
+)
+
+func TestAgent_RunResult(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("get_time", "", func() string { return "noon" })
+
+	first := toolCallResponse(toolCall("call_1", "get_time", `{}`))
+	first.Model = "fallback-model"
+	first.Usage = llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+	final := textResponse("It is noon.")
+	final.Model = "fallback-model"
+	final.Choices[0].FinishReason = "length"
+	final.Usage = llm.Usage{PromptTokens: 20, CompletionTokens: 3, TotalTokens: 23}
+
+	client, _ := newMockClient(t, first, final)
+	myAgent := New(client, "test-model", WithTools(registry))
+
+	result, err := myAgent.RunResult(context.Background(), "what time is it?")
+	if err != nil {
+		t.Fatalf("RunResult failed: %v", err)
+	}
+
+	if result.Content != "It is noon." {
+		t.Errorf("got content %q want %q", result.Content, "It is noon.")
+	}
+	if result.FinishReason != "length" {
+		t.Errorf("got finish reason %q want %q", result.FinishReason, "length")
+	}
+	if result.Model != "fallback-model" {
+		t.Errorf("got model %q want the one that answered", result.Model)
+	}
+	if want := (llm.Usage{PromptTokens: 30, CompletionTokens: 8, TotalTokens: 38}); result.Usage != want {
+		t.Errorf("got usage %+v want %+v", result.Usage, want)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Function.Name != "get_time" {
+		t.Errorf("got tool calls %+v want the get_time call", result.ToolCalls)
+	}
+}
+
+func TestAgent_FingerprintWarning(t *testing.T) {
+	withFingerprint := func(content, fingerprint string) llm.ChatResponse {
+		resp := textResponse(content)
+		resp.SystemFingerprint = fingerprint
+		return resp
+	}
+
+	client, _ := newMockClient(t,
+		withFingerprint("a", "fp_1"),
+		withFingerprint("b", "fp_1"),
+		withFingerprint("c", "fp_2"),
+	)
+
+	var changes []string
+	myAgent := New(client, "test-model", WithSeed(42), WithFingerprintWarning(func(previous, current string) {
+		changes = append(changes, previous+" -> "+current)
+	}))
+
+	var results []*RunResult
+	for _, msg := range []string{"one", "two", "three"} {
+		result, err := myAgent.RunResult(context.Background(), msg)
+		if err != nil {
+			t.Fatalf("RunResult failed: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	if results[2].SystemFingerprint != "fp_2" || results[2].Seed != 42 {
+		t.Errorf("got %+v want the fingerprint and seed on the result", results[2])
+	}
+	if results[1].FingerprintChanged(results[0]) {
+		t.Error("same fingerprint reported as changed")
+	}
+	if !results[2].FingerprintChanged(results[1]) {
+		t.Error("fp_1 -> fp_2 not detected")
+	}
+	if len(changes) != 1 || changes[0] != "fp_1 -> fp_2" {
+		t.Errorf("got warnings %v want exactly one for fp_1 -> fp_2", changes)
+	}
+
+	// without a seed nobody promised the same output, so no warning
+	unseeded := &RunResult{SystemFingerprint: "fp_3"}
+	if unseeded.FingerprintChanged(&RunResult{SystemFingerprint: "fp_4"}) {
+		t.Error("unseeded runs reported as changed")
+	}
+}
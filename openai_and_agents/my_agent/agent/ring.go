@@ -0,0 +1,102 @@
+package agent
+
+import "my_agent/llm"
+
+// WithRingBuffer caps History at capacity messages (the system prompt not counted), dropping the oldest first
+// unlike WithMaxHistoryMessages nothing is re-trimmed every turn: messages go into a fixed buffer with O(1)
+// amortized appends and History stays an ordered view into it, so a long running chat server stops allocating
+// tool results whose call was dropped go too, keep capacity well above the size of one tool round
+// editing History by hand is fine, the next append copies it into the buffer once
+//
+// the price of not allocating: the buffer slots are reused, so a History slice kept from before an append
+// (snap := a.History) can change under you, the system message moves and dropped slots get cleared
+// take slices.Clone(a.History) when you need the conversation as it was, the stores already copy
+func WithRingBuffer(capacity int) Option {
+	return func(a *Agent) {
+		if capacity > 0 {
+			a.ring = &historyRing{capacity: capacity}
+		}
+	}
+}
+
+// appendHistory is how the agent adds messages to History, through the ring when there is one
+func (a *Agent) appendHistory(msgs ...llm.Message) {
+	if a.ring == nil {
+		a.History = append(a.History, msgs...)
+		return
+	}
+	a.History = a.ring.append(a.History, msgs...)
+}
+
+// historyRing is a sliding window over a buffer twice the capacity
+// the view buf[start:end] moves forward as messages are dropped, the system message moving along with it
+// once the end of the buffer is reached the view is copied back to the front, that copy is what makes it amortized
+type historyRing struct {
+	capacity   int
+	buf        []llm.Message
+	start, end int
+}
+
+// append adds msgs to history and returns the new view
+// history is normally the previous view, anything else is adopted first
+func (r *historyRing) append(history []llm.Message, msgs ...llm.Message) []llm.Message {
+	if !r.owns(history) {
+		r.adopt(history)
+	}
+	for _, msg := range msgs {
+		r.push(msg)
+	}
+	return r.buf[r.start:r.end:r.end]
+}
+
+// owns reports whether history is still the view we handed out
+func (r *historyRing) owns(history []llm.Message) bool {
+	if r.buf == nil || len(history) != r.end-r.start {
+		return false
+	}
+	if len(history) == 0 {
+		return true
+	}
+	return &history[0] == &r.buf[r.start]
+}
+
+// adopt copies history into a fresh buffer, a new one so a view saved somewhere else (WithStateless) stays intact
+func (r *historyRing) adopt(history []llm.Message) {
+	r.buf = make([]llm.Message, 2*(r.capacity+1))
+	r.start, r.end = 0, 0
+	for _, msg := range history {
+		r.push(msg)
+	}
+}
+
+func (r *historyRing) hasSystem() bool {
+	return r.end > r.start && r.buf[r.start].IsSystem()
+}
+
+func (r *historyRing) push(msg llm.Message) {
+	if r.end == len(r.buf) {
+		n := copy(r.buf, r.buf[r.start:r.end])
+		clear(r.buf[n:])
+		r.start, r.end = 0, n
+	}
+	r.buf[r.end] = msg
+	r.end++
+
+	first := r.start
+	if r.hasSystem() {
+		first++
+	}
+	for r.end-first > r.capacity || (r.end-first > 1 && r.buf[first].IsToolResult()) {
+		r.dropOldest()
+		first++
+	}
+}
+
+// dropOldest removes the oldest non-system message, the system message (if any) moves into its slot
+func (r *historyRing) dropOldest() {
+	if r.hasSystem() {
+		r.buf[r.start+1] = r.buf[r.start]
+	}
+	r.buf[r.start] = llm.Message{}
+	r.start++
+}
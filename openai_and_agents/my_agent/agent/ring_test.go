@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"my_agent/llm"
+	"slices"
+	"testing"
+)
+
+func TestAgent_WithRingBuffer(t *testing.T) {
+	t.Run("capacity and order after wraparound", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "test-model", WithSystemPrompts("be brief"), WithRingBuffer(3))
+
+		// enough to wrap the buffer (twice the capacity) several times
+		for i := range 20 {
+			myAgent.AddMessage(llm.NewUserMessage(fmt.Sprint(i)))
+
+			want := []string{"be brief"}
+			for j := max(0, i-2); j <= i; j++ {
+				want = append(want, fmt.Sprint(j))
+			}
+			if got := contents(myAgent.History); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("after %d: got %v want %v", i, got, want)
+			}
+		}
+	})
+
+	t.Run("no orphaned tool results", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "test-model", WithRingBuffer(3))
+
+		myAgent.AddMessage(llm.NewUserMessage("weather?"))
+		myAgent.AddMessage(llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_1", "get_weather", `{}`)}))
+		myAgent.AddMessage(llm.NewToolResult("call_1", "sunny"))
+		myAgent.AddMessage(llm.NewAssistantMessage("It is sunny."))
+		myAgent.AddMessage(llm.NewUserMessage("thanks"))
+
+		// dropping the call takes its result along
+		if got := contents(myAgent.History); fmt.Sprint(got) != "[It is sunny. thanks]" {
+			t.Errorf("got %v want the call and its result dropped together", got)
+		}
+	})
+
+	t.Run("runs see the view", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("a"), textResponse("b"), textResponse("c"))
+		myAgent := New(client, "test-model", WithSystemPrompts("be brief"), WithRingBuffer(2))
+
+		for _, msg := range []string{"one", "two", "three"} {
+			if _, err := myAgent.Run(context.Background(), msg); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+		}
+
+		if got := contents(api.requests[2].Messages); fmt.Sprint(got) != "[be brief b three]" {
+			t.Errorf("sent %v want the system prompt and the last two messages", got)
+		}
+	})
+
+	t.Run("earlier slices are invalidated, clones are not", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "test-model", WithSystemPrompts("sys"), WithRingBuffer(3))
+		for i := range 3 {
+			myAgent.AddMessage(llm.NewUserMessage(fmt.Sprint(i)))
+		}
+
+		view := myAgent.History
+		clone := slices.Clone(myAgent.History)
+		myAgent.AddMessage(llm.NewUserMessage("3"))
+
+		// the documented catch: the old view shares slots with the ring
+		if fmt.Sprint(contents(view)) == "[sys 0 1 2]" {
+			t.Errorf("the old view %v was left alone, update the WithRingBuffer docs", contents(view))
+		}
+		if got := contents(clone); fmt.Sprint(got) != "[sys 0 1 2]" {
+			t.Errorf("got clone %v want it untouched", got)
+		}
+		if got := contents(myAgent.History); fmt.Sprint(got) != "[sys 1 2 3]" {
+			t.Errorf("got %v want [sys 1 2 3]", got)
+		}
+	})
+
+	t.Run("appends don't allocate", func(t *testing.T) {
+		client, _ := newMockClient(t)
+		myAgent := New(client, "test-model", WithSystemPrompts("be brief"), WithRingBuffer(8))
+		msg := llm.NewUserMessage("hi")
+		myAgent.AddMessage(msg)
+
+		if allocs := testing.AllocsPerRun(100, func() { myAgent.AddMessage(msg) }); allocs != 0 {
+			t.Errorf("got %v allocations per append want 0", allocs)
+		}
+	})
+}
+
+func contents(msgs []llm.Message) []string {
+	var out []string
+	for _, msg := range msgs {
+		out = append(out, msg.Content)
+	}
+	return out
+}
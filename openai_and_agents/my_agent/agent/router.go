@@ -0,0 +1,31 @@
+package agent
+
+import "my_agent/llm"
+
+// WithModelRouter picks the model for each Run from the user message, a cheap model for short questions
+// and a strong one for the hard ones for example
+// history is what came before the message, don't hold on to it
+// an empty answer falls back to Model, and without a router Model is always used
+func WithModelRouter(router func(msg string, history []llm.Message) string) Option {
+	return func(a *Agent) {
+		a.ModelRouter = router
+	}
+}
+
+// routeModel asks the router for the model of the run that is starting
+func (a *Agent) routeModel(usrMsg string) {
+	a.runModel = ""
+	if a.ModelRouter != nil {
+		var model string
+		a.unlocked(func() { model = a.ModelRouter(usrMsg, a.History) })
+		a.runModel = model
+	}
+}
+
+// model is the model of the current run, the routed one if there is one
+func (a *Agent) model() string {
+	if a.runModel != "" {
+		return a.runModel
+	}
+	return a.Model
+}
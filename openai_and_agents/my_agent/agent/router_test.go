@@ -0,0 +1,38 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+	"testing"
+)
+
+func TestAgent_WithModelRouter(t *testing.T) {
+	router := func(msg string, history []llm.Message) string {
+		if len(msg) > 20 {
+			return "strong-model"
+		}
+		return "cheap-model"
+	}
+
+	client, api := newMockClient(t, textResponse("hi"), textResponse("long answer"), textResponse("ok"))
+	myAgent := New(client, "default-model", WithModelRouter(router))
+
+	myAgent.Run(context.Background(), "hello")
+	myAgent.Run(context.Background(), "explain the borrow checker in detail")
+
+	if got := api.requests[0].Model; got != "cheap-model" {
+		t.Errorf("short message went to %q want cheap-model", got)
+	}
+	if got := api.requests[1].Model; got != "strong-model" {
+		t.Errorf("long message went to %q want strong-model", got)
+	}
+
+	t.Run("empty route falls back to Model", func(t *testing.T) {
+		myAgent.ModelRouter = func(string, []llm.Message) string { return "" }
+		myAgent.Run(context.Background(), "hello")
+
+		if got := api.requests[2].Model; got != "default-model" {
+			t.Errorf("got %q want default-model", got)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"my_agent/llm"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// HistoryStore is anything that can keep a conversation around between runs (memory, files, redis, sqlite ...)
+// Load of a session that was never saved returns an empty history and no error
+type HistoryStore interface {
+	Save(id string, msgs []llm.Message) error
+	Load(id string) ([]llm.Message, error)
+}
+
+// WithStore makes the agent load sessionID on its first Run and save the history after every Run
+// so a fresh agent per http request still continues the same conversation
+func WithStore(store HistoryStore, sessionID string) Option {
+	return func(a *Agent) {
+		a.store = store
+		a.sessionID = sessionID
+	}
+}
+
+// MemoryStore keeps sessions in a map, handy for tests and single process servers
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string][]llm.Message
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string][]llm.Message),
+	}
+}
+
+func (s *MemoryStore) Save(id string, msgs []llm.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// copy so the agent appending to its own slice later cant change what we stored
+	s.sessions[id] = append([]llm.Message(nil), msgs...)
+	return nil
+}
+
+func (s *MemoryStore) Load(id string) ([]llm.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]llm.Message(nil), s.sessions[id]...), nil
+}
+
+// FileStore writes each session as <dir>/<id>.json
+type FileStore struct {
+	Dir string
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (s *FileStore) Save(id string, msgs []llm.Message) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create store dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(msgs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal history: %w", err)
+	}
+
+	// write to a temp file first and rename, a crash mid write then never leaves half a session behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("unable to write session %q: %w", id, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *FileStore) Load(id string) ([]llm.Message, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read session %q: %w", id, err)
+	}
+
+	var msgs []llm.Message
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return nil, fmt.Errorf("unable to decode session %q: %w", id, err)
+	}
+	return msgs, nil
+}
+
+// the id ends up in a file name so dont let it walk out of the store dir
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"my_agent/llm"
+	"testing"
+)
+
+func TestAgent_WithStore(t *testing.T) {
+	store := NewMemoryStore()
+
+	client, api := newMockClient(t,
+		textResponse("Hi Carol"),
+		textResponse("Your name is Carol"),
+	)
+
+	// 1. first agent starts the session
+	first := New(client, "test-model", WithSystemPrompts("be nice"), WithStore(store, "session-1"))
+	if _, err := first.Run(context.Background(), "My name is Carol"); err != nil {
+		t.Fatalf("first Run failed: %v", err)
+	}
+
+	saved, _ := store.Load("session-1")
+	if len(saved) != 3 {
+		t.Fatalf("got %d saved messages want 3 (system, user, assistant)", len(saved))
+	}
+
+	// 2. a brand new agent (like the next http request) picks it up from the store
+	second := New(client, "test-model", WithSystemPrompts("be nice"), WithStore(store, "session-1"))
+	got, err := second.Run(context.Background(), "What is my name?")
+	if err != nil {
+		t.Fatalf("second Run failed: %v", err)
+	}
+	if got != "Your name is Carol" {
+		t.Errorf("got %q want %q", got, "Your name is Carol")
+	}
+
+	// the second request must carry the whole first exchange and only one system prompt
+	msgs := api.requests[1].Messages
+	if len(msgs) != 4 {
+		t.Fatalf("got %d messages in the second request want 4", len(msgs))
+	}
+	if msgs[0].Role != "system" || msgs[1].Content != "My name is Carol" || msgs[3].Content != "What is my name?" {
+		t.Errorf("unexpected history sent: %+v", msgs)
+	}
+
+	saved, _ = store.Load("session-1")
+	if len(saved) != 5 {
+		t.Errorf("got %d saved messages want 5", len(saved))
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+
+	msgs, err := store.Load("missing")
+	if err != nil || len(msgs) != 0 {
+		t.Fatalf("got (%v, %v) want an empty history for an unknown session", msgs, err)
+	}
+
+	client, _ := newMockClient(t, textResponse("hello"))
+	myAgent := New(client, "test-model", WithStore(store, "abc"))
+	if _, err := myAgent.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	msgs, err = store.Load("abc")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(msgs) != 2 || msgs[1].Content != "hello" {
+		t.Errorf("got %+v, want the user and assistant messages", msgs)
+	}
+
+	if err := store.Save("../escape", msgs); err == nil {
+		t.Error("expected an error for a session id with a path in it")
+	}
+}
+
+func TestFileStore_KeepsMessageNames(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+
+	client, _ := newMockClient(t, textResponse("pizza it is"))
+	myAgent := New(client, "test-model", WithStore(store, "group"))
+
+	// a named message goes straight into history, the blank Run then answers it
+	vote, err := llm.NewNamedUserMessage("alice", "I vote for pizza")
+	if err != nil {
+		t.Fatalf("NewNamedUserMessage failed: %v", err)
+	}
+	myAgent.History = append(myAgent.History, vote)
+	if _, err := myAgent.Run(context.Background(), ""); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	msgs, err := store.Load("group")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if msgs[0].Name != "alice" || msgs[0].Content != "I vote for pizza" {
+		t.Errorf("got %+v, the name should survive a save and load", msgs[0])
+	}
+}
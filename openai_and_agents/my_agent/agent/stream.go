@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"my_agent/llm"
+	"net/http"
+	"strings"
+)
+
+// RunStream is Run with the reply coming in token by token
+// it streams a single assistant turn so tools are not executed here, use Run for tool using agents
+// history is only updated once the stream is over
+//
+// the caller must drain the token channel or cancel ctx, until the stream is over other runs
+// and the methods changing History wait for it, the read only ones (Transcript, TotalCost ...) keep working
+//
+// if the stream breaks after some text arrived the partial reply is still added to history
+// and the error channel carries the *llm.StreamError (with the same Partial text)
+// a stream that broke before any text arrived leaves only the user message behind
+func (a *Agent) RunStream(ctx context.Context, usrMsg string) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	errs := make(chan error, 1)
+
+	// runMu is held until the stream is over, the goroutine below unlocks it
+	// a.mu only while the history is touched, not while tokens are sent
+	a.lockRun()
+
+	if err := a.loadSession(); err != nil {
+		a.unlockRun()
+		close(tokens)
+		errs <- err
+		close(errs)
+		return tokens, errs
+	}
+
+	restore := func() {}
+	if a.Stateless {
+		restore = a.statelessHistory()
+	}
+
+	if err := a.checkInput(usrMsg); err != nil {
+		restore()
+		a.unlockRun()
+		close(tokens)
+		errs <- err
+		close(errs)
+		return tokens, errs
+	}
+
+	a.routeModel(usrMsg)
+	if strings.TrimSpace(usrMsg) != "" {
+		a.appendHistory(llm.NewUserMessage(usrMsg))
+	}
+	a.trimHistory()
+	a.trimHistoryTokens()
+
+	req := a.buildRequest()
+	req.Tools = nil
+	a.runModel = ""
+	a.mu.Unlock()
+
+	go func() {
+		defer close(errs)
+		defer close(tokens)
+		defer a.runMu.Unlock()
+
+		upstream, upstreamErrs := a.client.CreateChatStream(ctx, req)
+
+		// once ctx is cancelled nobody may be reading tokens anymore, so stop sending
+		// and keep draining upstream instead, it closes soon since it runs on the same ctx
+		var reply strings.Builder
+		for token := range upstream {
+			reply.WriteString(token)
+			if ctx.Err() != nil {
+				continue
+			}
+			select {
+			case tokens <- token:
+			case <-ctx.Done():
+			}
+		}
+		err := <-upstreamErrs
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		defer func() { a.turns++ }()
+
+		// a clean stream or a recoverable break both leave the text we got in history
+		var streamErr *llm.StreamError
+		if err == nil || (errors.As(err, &streamErr) && streamErr.Partial != "") {
+			a.appendHistory(llm.NewAssistantMessage(reply.String()))
+		}
+
+		restore()
+
+		if saveErr := a.saveSession(); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return tokens, errs
+}
+
+// RunStreamTo is RunStream for when all you want is the tokens printed as they arrive (os.Stdout in a CLI)
+// every token is written to w and flushed if w can flush, the full reply is returned at the end
+//
+// on a broken stream the text received so far is returned together with the error
+// if writing to w fails the stream is cancelled and the write error is returned
+func (a *Agent) RunStreamTo(ctx context.Context, w io.Writer, usrMsg string) (string, error) {
+	// cancelled on a write error, so the stream stops instead of producing tokens nobody reads
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tokens, errs := a.RunStream(ctx, usrMsg)
+
+	var reply strings.Builder
+	var writeErr error
+	for token := range tokens {
+		// keep draining after a failed write, RunStream only finishes once the channel is empty
+		if writeErr != nil {
+			continue
+		}
+		reply.WriteString(token)
+
+		if _, err := io.WriteString(w, token); err != nil {
+			writeErr = fmt.Errorf("unable to write token: %w", err)
+			cancel()
+			continue
+		}
+		if err := flush(w); err != nil {
+			writeErr = fmt.Errorf("unable to flush: %w", err)
+			cancel()
+		}
+	}
+	err := <-errs
+
+	if writeErr != nil {
+		return reply.String(), writeErr
+	}
+	return reply.String(), err
+}
+
+// flush pushes buffered output through for the writers that buffer (bufio.Writer, http.ResponseWriter ...)
+func flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case http.Flusher:
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"my_agent/llm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAgent_RunStreamPartial(t *testing.T) {
+	// the body stops mid sentence without [DONE], like a dropped connection
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, content := range []string{"Arr, the", " treasure is"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+	}))
+	defer server.Close()
+
+	client, err := llm.NewClient("test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+
+	myAgent := New(client, "test-model", WithSystemPrompts("talk like a pirate"))
+
+	tokens, errs := myAgent.RunStream(context.Background(), "where is the treasure?")
+
+	var got strings.Builder
+	for token := range tokens {
+		got.WriteString(token)
+	}
+	err = <-errs
+
+	if got.String() != "Arr, the treasure is" {
+		t.Errorf("got %q want %q", got.String(), "Arr, the treasure is")
+	}
+
+	var streamErr *llm.StreamError
+	if !errors.As(err, &streamErr) {
+		t.Fatalf("got %v, want a *llm.StreamError", err)
+	}
+
+	// system, user and the partial assistant reply
+	if len(myAgent.History) != 3 {
+		t.Fatalf("got %d messages in history want 3", len(myAgent.History))
+	}
+	last := myAgent.History[2]
+	if last.Role != "assistant" || last.Content != "Arr, the treasure is" {
+		t.Errorf("got %+v, want the partial assistant reply", last)
+	}
+}
+
+// flushCounter is a bytes.Buffer that counts how often it got flushed
+type flushCounter struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (f *flushCounter) Flush() error {
+	f.flushes++
+	return nil
+}
+
+// failingWriter accepts n writes and then breaks, like a closed pipe
+type failingWriter struct {
+	n int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.n == 0 {
+		return 0, io.ErrClosedPipe
+	}
+	f.n--
+	return len(p), nil
+}
+
+func sseServer(t *testing.T, done bool, chunks ...string) *llm.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, content := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		if done {
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := llm.NewClient("test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+	return client
+}
+
+func TestAgent_RunStreamTo(t *testing.T) {
+	t.Run("writes every token", func(t *testing.T) {
+		myAgent := New(sseServer(t, true, "Hello", ", ", "world"), "test-model")
+
+		var out flushCounter
+		reply, err := myAgent.RunStreamTo(context.Background(), &out, "hi")
+		if err != nil {
+			t.Fatalf("RunStreamTo failed: %v", err)
+		}
+
+		if out.String() != "Hello, world" || reply != "Hello, world" {
+			t.Errorf("got written %q returned %q want %q for both", out.String(), reply, "Hello, world")
+		}
+		if out.flushes != 3 {
+			t.Errorf("got %d flushes want one per token", out.flushes)
+		}
+	})
+
+	t.Run("broken stream returns the partial reply", func(t *testing.T) {
+		myAgent := New(sseServer(t, false, "Hel", "lo"), "test-model")
+
+		var out bytes.Buffer
+		reply, err := myAgent.RunStreamTo(context.Background(), &out, "hi")
+
+		var streamErr *llm.StreamError
+		if !errors.As(err, &streamErr) {
+			t.Errorf("got %v want a *llm.StreamError", err)
+		}
+		if reply != "Hello" || out.String() != "Hello" {
+			t.Errorf("got written %q returned %q want %q", out.String(), reply, "Hello")
+		}
+	})
+
+	t.Run("write errors stop the stream", func(t *testing.T) {
+		myAgent := New(sseServer(t, true, "one", "two", "three"), "test-model")
+
+		_, err := myAgent.RunStreamTo(context.Background(), &failingWriter{n: 1}, "hi")
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("got %v want the write error", err)
+		}
+	})
+}
+
+func TestAgent_RunStreamStopsWhenTheCallerGoesAway(t *testing.T) {
+	myAgent := New(sseServer(t, true, "one", "two", "three", "four"), "test-model")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens, errs := myAgent.RunStream(ctx, "count")
+	<-tokens
+	// the caller stops reading tokens, cancelling must be enough to let RunStream finish
+	cancel()
+
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunStream is stuck sending tokens nobody reads")
+	}
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"my_agent/llm"
+)
+
+// RunWithTools is Run with only the named tools offered to the model, the registry itself is left alone
+// handy for gating tools by the phase of a conversation, e.g. no "checkout" before the cart is filled
+// a name that isn't registered is an error, calls the model makes to a tool outside the list are refused
+func (a *Agent) RunWithTools(ctx context.Context, usrMsg string, allowedNames ...string) (string, error) {
+	allowed, err := a.toolScope(allowedNames)
+	if err != nil {
+		return "", err
+	}
+
+	a.lockRun()
+	defer a.unlockRun()
+
+	a.allowedTools = allowed
+	defer func() { a.allowedTools = nil }()
+
+	result, err := a.runSessionLocked(ctx, usrMsg, nil)
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+// toolScope checks the names against the registry and turns them into a set
+func (a *Agent) toolScope(names []string) (map[string]bool, error) {
+	registered := make(map[string]bool)
+	if a.Tools != nil {
+		for _, def := range a.Tools.Definitions() {
+			registered[def.Function.Name] = true
+		}
+	}
+
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !registered[name] {
+			return nil, fmt.Errorf("tool %q is not registered", name)
+		}
+		allowed[name] = true
+	}
+	return allowed, nil
+}
+
+// scopeTools drops the definitions RunWithTools didn't allow, nil allowed means every tool
+func scopeTools(defs []llm.Tool, allowed map[string]bool) []llm.Tool {
+	if allowed == nil {
+		return defs
+	}
+
+	scoped := make([]llm.Tool, 0, len(allowed))
+	for _, def := range defs {
+		if allowed[def.Function.Name] {
+			scoped = append(scoped, def)
+		}
+	}
+	return scoped
+}
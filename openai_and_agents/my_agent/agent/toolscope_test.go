@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"my_agent/tools"
+	"strings"
+	"testing"
+)
+
+func TestAgent_RunWithTools(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("search_products", "", func() string { return "3 shoes" })
+	registry.Register("checkout", "", func() string { return "paid" })
+
+	t.Run("only the allowed tools are offered", func(t *testing.T) {
+		client, api := newMockClient(t,
+			// the model tries the tool it wasn't offered anyway
+			toolCallResponse(toolCall("call_1", "checkout", `{}`)),
+			textResponse("Let me find you some shoes first."),
+			textResponse("ok"),
+		)
+		myAgent := New(client, "test-model", WithTools(registry))
+
+		if _, err := myAgent.RunWithTools(context.Background(), "buy shoes", "search_products"); err != nil {
+			t.Fatalf("RunWithTools failed: %v", err)
+		}
+
+		defs := api.requests[0].Tools
+		if len(defs) != 1 || defs[0].Function.Name != "search_products" {
+			t.Errorf("got tools %+v want only search_products", defs)
+		}
+
+		result := myAgent.History[len(myAgent.History)-2]
+		if !result.IsToolResult() || !strings.Contains(result.Content, "not available") {
+			t.Errorf("got %+v want the checkout call refused", result)
+		}
+
+		// the next plain Run offers everything again
+		myAgent.Run(context.Background(), "now pay")
+		if len(api.requests[2].Tools) != 2 {
+			t.Errorf("got %d tools after RunWithTools want 2", len(api.requests[2].Tools))
+		}
+	})
+
+	t.Run("unknown names are an error", func(t *testing.T) {
+		client, api := newMockClient(t, textResponse("ok"))
+		myAgent := New(client, "test-model", WithTools(registry))
+
+		if _, err := myAgent.RunWithTools(context.Background(), "hi", "refund"); err == nil {
+			t.Error("expected an error for an unregistered tool")
+		}
+		if len(api.requests) != 0 {
+			t.Errorf("got %d requests want none", len(api.requests))
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"encoding/json"
+	"my_agent/llm"
+)
+
+// TraceStep is one model round trip of a Run: what was asked, what came back and what the tools answered
+type TraceStep struct {
+	Step int `json:"step"`
+	// request summary, the full messages are in History already
+	Model    string   `json:"model"`
+	Messages int      `json:"messages"`
+	Tools    []string `json:"tools,omitempty"`
+
+	Response     *llm.Message `json:"response,omitempty"`
+	FinishReason string       `json:"finish_reason,omitempty"`
+	Usage        llm.Usage    `json:"usage"`
+
+	ToolResults []TraceToolResult `json:"tool_results,omitempty"`
+	// set when the model call itself failed, the run ended there
+	Error string `json:"error,omitempty"`
+}
+
+// TraceToolResult is the answer one tool call got
+type TraceToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+}
+
+// LastTraceJSON is the most recent Run step by step as json, for trace viewers and for debugging
+// why the agent did what it did, Transcript is the human readable counterpart
+// a run that failed keeps the steps it got through, null when nothing ran yet
+func (a *Agent) LastTraceJSON() ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return json.MarshalIndent(a.trace, "", "  ")
+}
+
+// traceCall records a model call, err is the failed call's error
+func (a *Agent) traceCall(req llm.ChatRequest, resp *llm.ChatResponse, err error) {
+	step := TraceStep{
+		Step:     len(a.trace) + 1,
+		Model:    req.Model,
+		Messages: len(req.Messages),
+	}
+	for _, tool := range req.Tools {
+		step.Tools = append(step.Tools, tool.Function.Name)
+	}
+
+	if err != nil {
+		step.Error = err.Error()
+	} else {
+		if resp.Model != "" {
+			step.Model = resp.Model
+		}
+		step.Usage = resp.Usage
+		if len(resp.Choices) > 0 {
+			step.Response = &resp.Choices[0].Message
+			step.FinishReason = resp.Choices[0].FinishReason
+		}
+	}
+	a.trace = append(a.trace, step)
+}
+
+// traceToolResults adds the results of the last step's tool calls to it
+func (a *Agent) traceToolResults(calls []llm.ToolCall, results []llm.Message) {
+	if len(a.trace) == 0 {
+		return
+	}
+
+	names := make(map[string]string, len(calls))
+	for _, call := range calls {
+		names[call.ID] = call.Function.Name
+	}
+
+	step := &a.trace[len(a.trace)-1]
+	for _, result := range results {
+		step.ToolResults = append(step.ToolResults, TraceToolResult{
+			ToolCallID: result.ToolCallID,
+			Name:       names[result.ToolCallID],
+			Content:    result.Content,
+		})
+	}
+}
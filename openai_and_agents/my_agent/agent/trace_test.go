@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"my_agent/tools"
+	"testing"
+)
+
+func TestAgent_LastTraceJSON(t *testing.T) {
+	registry := tools.NewRegistry()
+	registry.Register("get_weather", "Get current weather", func(args struct {
+		City string `json:"city"`
+	}) string {
+		return "sunny in " + args.City
+	})
+
+	client, _ := newMockClient(t,
+		toolCallResponse(toolCall("call_1", "get_weather", `{"city":"Paris"}`)),
+		textResponse("It is sunny in Paris."),
+	)
+	myAgent := New(client, "test-model", WithTools(registry))
+
+	if _, err := myAgent.Run(context.Background(), "weather in Paris?"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	data, err := myAgent.LastTraceJSON()
+	if err != nil {
+		t.Fatalf("LastTraceJSON failed: %v", err)
+	}
+
+	var trace []TraceStep
+	if err := json.Unmarshal(data, &trace); err != nil {
+		t.Fatalf("trace is not valid json: %v\n%s", err, data)
+	}
+	if len(trace) != 2 {
+		t.Fatalf("got %d steps want 2:\n%s", len(trace), data)
+	}
+
+	first := trace[0]
+	if first.Response == nil || len(first.Response.ToolCalls) != 1 || first.Response.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("got first response %+v want the get_weather call", first.Response)
+	}
+	if len(first.Tools) != 1 || first.Tools[0] != "get_weather" {
+		t.Errorf("got offered tools %v want [get_weather]", first.Tools)
+	}
+	want := TraceToolResult{ToolCallID: "call_1", Name: "get_weather", Content: "sunny in Paris"}
+	if len(first.ToolResults) != 1 || first.ToolResults[0] != want {
+		t.Errorf("got tool results %+v want %+v", first.ToolResults, want)
+	}
+
+	if final := trace[1]; final.Response == nil || final.Response.Content != "It is sunny in Paris." || final.FinishReason != "stop" {
+		t.Errorf("got final step %+v want the answer", final)
+	}
+
+	// the next run starts a fresh trace
+	myAgent.Run(context.Background(), "and tomorrow?")
+	data, _ = myAgent.LastTraceJSON()
+	json.Unmarshal(data, &trace)
+	if len(trace) != 1 || trace[0].Error == "" {
+		t.Errorf("got %s want one failed step, the mock has no responses left", data)
+	}
+}
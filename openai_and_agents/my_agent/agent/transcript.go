@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"fmt"
+	"my_agent/llm"
+	"strings"
+)
+
+// TranscriptFormat picks how Transcript renders the history
+type TranscriptFormat int
+
+const (
+	// TranscriptMarkdown has a header per turn and tool calls and results in code blocks
+	TranscriptMarkdown TranscriptFormat = iota
+	// TranscriptText is one "Role: content" paragraph per turn
+	TranscriptText
+)
+
+// Transcript renders the history as something a person can read, for bug reports or docs
+func (a *Agent) Transcript(format TranscriptFormat) (string, error) {
+	if format != TranscriptMarkdown && format != TranscriptText {
+		return "", fmt.Errorf("unknown transcript format %d", format)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// tool results only carry the call id, the name is on the call
+	toolNames := make(map[string]string)
+	for _, msg := range a.History {
+		for _, call := range msg.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+	}
+
+	var b strings.Builder
+	for _, msg := range a.History {
+		if format == TranscriptMarkdown {
+			writeMarkdownTurn(&b, msg, toolNames)
+		} else {
+			writeTextTurn(&b, msg, toolNames)
+		}
+	}
+	out := strings.TrimRight(b.String(), "\n")
+	if out == "" {
+		return "", nil
+	}
+	return out + "\n", nil
+}
+
+func writeMarkdownTurn(b *strings.Builder, msg llm.Message, toolNames map[string]string) {
+	fmt.Fprintf(b, "## %s\n\n", speaker(msg, toolNames))
+
+	if msg.IsToolResult() {
+		fence := codeFence(msg.Content)
+		fmt.Fprintf(b, "%s\n%s\n%s\n\n", fence, msg.Content, fence)
+		return
+	}
+
+	if msg.Content != "" {
+		fmt.Fprintf(b, "%s\n\n", msg.Content)
+	}
+	for _, call := range msg.ToolCalls {
+		fence := codeFence(call.Function.Arguments)
+		fmt.Fprintf(b, "Tool call `%s`:\n\n%sjson\n%s\n%s\n\n", call.Function.Name, fence, call.Function.Arguments, fence)
+	}
+}
+
+// codeFence is a backtick fence longer than any backtick run in content (and at least three)
+// so a tool output that contains its own ``` block can't end ours early
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func writeTextTurn(b *strings.Builder, msg llm.Message, toolNames map[string]string) {
+	if msg.Content != "" || !msg.IsToolCall() {
+		fmt.Fprintf(b, "%s: %s\n\n", speaker(msg, toolNames), msg.Content)
+	}
+	for _, call := range msg.ToolCalls {
+		fmt.Fprintf(b, "Assistant called %s(%s)\n\n", call.Function.Name, call.Function.Arguments)
+	}
+}
+
+// speaker is the heading for a turn: the role, plus the name for named messages and the tool for results
+func speaker(msg llm.Message, toolNames map[string]string) string {
+	role := msg.Role
+	if role != "" {
+		role = strings.ToUpper(role[:1]) + role[1:]
+	}
+
+	switch {
+	case msg.IsToolResult():
+		return fmt.Sprintf("%s (%s)", role, toolNames[msg.ToolCallID])
+	case msg.Name != "":
+		return fmt.Sprintf("%s (%s)", role, msg.Name)
+	}
+	return role
+}
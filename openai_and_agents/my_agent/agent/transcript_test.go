@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"my_agent/llm"
+	"strings"
+	"testing"
+)
+
+func transcriptAgent() *Agent {
+	a := New(nil, "test-model", WithSystemPrompts("You know the weather."))
+	a.History = append(a.History,
+		llm.NewUserMessage("Weather in Paris?"),
+		llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_1", "get_weather", `{"city":"Paris"}`)}),
+		llm.NewToolResult("call_1", "sunny, 24C"),
+		llm.NewAssistantMessage("It is sunny and 24C in Paris."),
+	)
+	return a
+}
+
+func TestAgent_TranscriptMarkdown(t *testing.T) {
+	got, err := transcriptAgent().Transcript(TranscriptMarkdown)
+	if err != nil {
+		t.Fatalf("Transcript failed: %v", err)
+	}
+
+	want := "## System\n\nYou know the weather.\n\n" +
+		"## User\n\nWeather in Paris?\n\n" +
+		"## Assistant\n\nTool call `get_weather`:\n\n```json\n{\"city\":\"Paris\"}\n```\n\n" +
+		"## Tool (get_weather)\n\n```\nsunny, 24C\n```\n\n" +
+		"## Assistant\n\nIt is sunny and 24C in Paris.\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAgent_TranscriptText(t *testing.T) {
+	got, err := transcriptAgent().Transcript(TranscriptText)
+	if err != nil {
+		t.Fatalf("Transcript failed: %v", err)
+	}
+
+	want := "System: You know the weather.\n\n" +
+		"User: Weather in Paris?\n\n" +
+		"Assistant called get_weather({\"city\":\"Paris\"})\n\n" +
+		"Tool (get_weather): sunny, 24C\n\n" +
+		"Assistant: It is sunny and 24C in Paris.\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := transcriptAgent().Transcript(TranscriptFormat(42)); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := (&Agent{}).Transcript(TranscriptFormat(42)); err == nil {
+		t.Error("expected an error for an unknown format even with an empty history")
+	}
+}
+
+func TestAgent_TranscriptMarkdownNestedFence(t *testing.T) {
+	// a whole file coming back from a tool, with its own code block inside
+	output := "# README\n\n```go\nfmt.Println(\"hi\")\n```"
+
+	a := New(nil, "test-model")
+	a.History = append(a.History,
+		llm.NewToolCallMessage([]llm.ToolCall{toolCall("call_1", "read_file", `{"path":"README.md"}`)}),
+		llm.NewToolResult("call_1", output),
+	)
+
+	got, err := a.Transcript(TranscriptMarkdown)
+	if err != nil {
+		t.Fatalf("Transcript failed: %v", err)
+	}
+
+	want := "## Tool (read_file)\n\n````\n" + output + "\n````\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("got\n%s\nwant it to end with\n%s", got, want)
+	}
+}
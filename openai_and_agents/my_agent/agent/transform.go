@@ -0,0 +1,28 @@
+package agent
+
+// WithInputTransform rewrites the user message before Run sends it, appending a reminder for example
+// transforms run in the order they were added, each one gets what the previous returned
+// the rewritten message is what goes into History, and what WithMaxInputBytes measures
+// a blank message (resuming a tool loop) is left alone
+func WithInputTransform(transform func(string) string) Option {
+	return func(a *Agent) {
+		a.inputTransforms = append(a.inputTransforms, transform)
+	}
+}
+
+// WithOutputTransform post processes the final answer of Run, stripping a prefix for example
+// transforms run in the order they were added, the result is both returned and kept in History
+// text between tool calls is not touched, only the final answer
+func WithOutputTransform(transform func(string) string) Option {
+	return func(a *Agent) {
+		a.outputTransforms = append(a.outputTransforms, transform)
+	}
+}
+
+// applyTransforms runs s through every transform in order
+func applyTransforms(s string, transforms []func(string) string) string {
+	for _, transform := range transforms {
+		s = transform(s)
+	}
+	return s
+}
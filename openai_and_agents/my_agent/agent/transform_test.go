@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestAgent_Transforms(t *testing.T) {
+	client, api := newMockClient(t, textResponse("Assistant: Paris."))
+	myAgent := New(client, "test-model",
+		WithInputTransform(strings.TrimSpace),
+		WithInputTransform(func(s string) string { return s + "\n(answer in one word)" }),
+		WithOutputTransform(func(s string) string { return strings.TrimPrefix(s, "Assistant: ") }),
+		WithOutputTransform(strings.ToUpper),
+	)
+
+	reply, err := myAgent.Run(context.Background(), "  capital of France?  ")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// registration order: trimmed first, then the reminder appended
+	sent := api.requests[0].Messages[0].Content
+	if want := "capital of France?\n(answer in one word)"; sent != want {
+		t.Errorf("sent %q want %q", sent, want)
+	}
+
+	// the prefix is stripped before upper casing, the other order would leave "ASSISTANT: " behind
+	if reply != "PARIS." {
+		t.Errorf("got reply %q want %q", reply, "PARIS.")
+	}
+	if last := myAgent.History[len(myAgent.History)-1].Content; last != reply {
+		t.Errorf("history has %q want the transformed reply", last)
+	}
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff works out how long to wait before the next retry attempt
+// the delay grows as BaseDelay * Multiplier^attempt and never goes above MaxDelay
+// it is exported so tools hitting flaky apis can reuse the same waiting strategy as the client
+type Backoff struct {
+	BaseDelay  time.Duration
+	Multiplier float64
+	MaxDelay   time.Duration
+
+	// Jitter is a fraction between 0 and 1, 0.2 means every delay is randomly moved by up to +-20%
+	// this stops a bunch of clients that failed together from retrying together
+	Jitter float64
+}
+
+// DefaultBackoff is what NewClient uses: 500ms, 1s, 2s, 4s ... capped at 30s
+func DefaultBackoff() Backoff {
+	return Backoff{
+		BaseDelay:  500 * time.Millisecond,
+		Multiplier: 2,
+		MaxDelay:   30 * time.Second,
+		Jitter:     0.2,
+	}
+}
+
+// NextDelay returns the wait before retry number attempt (0 is the first retry)
+func (b Backoff) NextDelay(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(b.BaseDelay) * math.Pow(multiplier, float64(attempt))
+
+	if b.Jitter > 0 {
+		// rand.Float64 is in [0,1) so this spreads the delay to [1-jitter, 1+jitter)
+		delay = delay * (1 + b.Jitter*(2*rand.Float64()-1))
+	}
+
+	// cap after the jitter so MaxDelay is a hard ceiling
+	if b.MaxDelay > 0 && delay > float64(b.MaxDelay) {
+		return b.MaxDelay
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// WithOnRetry calls fn before every retry wait of CreateChat, to see how flaky an endpoint is without full logging
+// attempt is the retry about to happen (1 for the first), err is what failed and delay how long we will wait
+// fn runs inside the retry loop, it has to return quickly: hand anything slow off to a goroutine or a channel
+func WithOnRetry(fn func(attempt int, err error, delay time.Duration)) ClientOption {
+	return func(c *Client) {
+		c.OnRetry = fn
+	}
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackoff_NextDelay(t *testing.T) {
+	b := Backoff{
+		BaseDelay:  100 * time.Millisecond,
+		Multiplier: 2,
+		MaxDelay:   time.Second,
+	}
+
+	t.Run("delays grow with every attempt", func(t *testing.T) {
+		want := []time.Duration{
+			100 * time.Millisecond,
+			200 * time.Millisecond,
+			400 * time.Millisecond,
+			800 * time.Millisecond,
+		}
+
+		for attempt, w := range want {
+			if got := b.NextDelay(attempt); got != w {
+				t.Errorf("attempt %d: got %v want %v", attempt, got, w)
+			}
+		}
+	})
+
+	t.Run("delays are capped at max", func(t *testing.T) {
+		for _, attempt := range []int{4, 5, 10, 100} {
+			if got := b.NextDelay(attempt); got != b.MaxDelay {
+				t.Errorf("attempt %d: got %v want the cap %v", attempt, got, b.MaxDelay)
+			}
+		}
+	})
+
+	t.Run("jitter stays inside its spread and under the cap", func(t *testing.T) {
+		jittered := b
+		jittered.Jitter = 0.5
+
+		for range 100 {
+			got := jittered.NextDelay(1)
+			if got < 100*time.Millisecond || got > 300*time.Millisecond {
+				t.Fatalf("got %v, want between 100ms and 300ms", got)
+			}
+			if capped := jittered.NextDelay(10); capped > b.MaxDelay {
+				t.Fatalf("got %v, want at most %v", capped, b.MaxDelay)
+			}
+		}
+	})
+}
+
+func TestClient_WithOnRetry(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	})
+	client.Backoff = Backoff{BaseDelay: 10 * time.Millisecond, Multiplier: 2}
+	client.Clock = newFakeClock()
+
+	var attempts []int
+	var delays []time.Duration
+	WithOnRetry(func(attempt int, err error, delay time.Duration) {
+		var serverErr *ServerError
+		if !errors.As(err, &serverErr) {
+			t.Errorf("attempt %d: got %v want the 503", attempt, err)
+		}
+		attempts = append(attempts, attempt)
+		delays = append(delays, delay)
+	})(client)
+
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+	if _, err := client.CreateChat(context.Background(), req); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	if !slices.Equal(attempts, []int{1, 2}) {
+		t.Errorf("got attempts %v want [1 2]", attempts)
+	}
+	if !slices.Equal(delays, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}) {
+		t.Errorf("got delays %v want the backoff delays", delays)
+	}
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// BatchResult is what CreateChatBatch hands back, Responses[i] and Errs[i] belong to the i-th request
+// exactly one of the two is set for every index
+type BatchResult struct {
+	Responses []*ChatResponse
+	Errs      []error
+}
+
+// CreateChatBatch sends every request at once and waits for all of them
+// one failing doesn't stop the others, look at the helpers on BatchResult to see how it went
+func (c *Client) CreateChatBatch(ctx context.Context, reqs []ChatRequest) *BatchResult {
+	result := &BatchResult{
+		Responses: make([]*ChatResponse, len(reqs)),
+		Errs:      make([]error, len(reqs)),
+	}
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every goroutine writes only its own index so no lock is needed
+			result.Responses[i], result.Errs[i] = c.CreateChat(ctx, req)
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
+
+// Errors are the failures in request order, nil when everything went through
+func (b *BatchResult) Errors() []error {
+	var errs []error
+	for _, err := range b.Errs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Successes are the responses of the requests that worked, in request order
+func (b *BatchResult) Successes() []*ChatResponse {
+	var responses []*ChatResponse
+	for i, resp := range b.Responses {
+		if b.Errs[i] == nil && resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	return responses
+}
+
+// FirstError is the failure of the lowest numbered request, nil if none failed
+func (b *BatchResult) FirstError() error {
+	for i, err := range b.Errs {
+		if err != nil {
+			return fmt.Errorf("batch request %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// JoinErrors puts every failure into one error with errors.Join, each tagged with its request index
+// errors.Is and errors.As still see the individual errors
+func (b *BatchResult) JoinErrors() error {
+	var errs []error
+	for i, err := range b.Errs {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("batch request %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
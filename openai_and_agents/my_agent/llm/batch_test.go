@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_CreateChatBatch(t *testing.T) {
+	// the "reject" model gets a 401, everything else answers with its own model name
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model == "reject" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Model: req.Model, Choices: []Choice{{Message: NewAssistantMessage("ok")}}})
+	})
+
+	request := func(model string) ChatRequest {
+		return ChatRequest{Model: model, Messages: []Message{NewUserMessage("hi")}}
+	}
+	batch := client.CreateChatBatch(context.Background(), []ChatRequest{
+		request("first"), request("reject"), request("second"), request("reject"),
+	})
+
+	successes := batch.Successes()
+	if len(successes) != 2 || successes[0].Model != "first" || successes[1].Model != "second" {
+		t.Errorf("got successes %+v want first and second in order", successes)
+	}
+
+	if errs := batch.Errors(); len(errs) != 2 {
+		t.Errorf("got %d errors want 2", len(errs))
+	}
+
+	first := batch.FirstError()
+	if first == nil || !strings.Contains(first.Error(), "batch request 1") {
+		t.Errorf("got first error %v want the one from request 1", first)
+	}
+
+	joined := batch.JoinErrors()
+	var authErr *AuthError
+	if !errors.As(joined, &authErr) {
+		t.Errorf("got %v want the auth errors reachable with errors.As", joined)
+	}
+	if !strings.Contains(joined.Error(), "batch request 3") {
+		t.Errorf("got %q want every failure in the joined error", joined)
+	}
+
+	t.Run("all good", func(t *testing.T) {
+		batch := client.CreateChatBatch(context.Background(), []ChatRequest{request("first")})
+		if batch.Errors() != nil || batch.FirstError() != nil || batch.JoinErrors() != nil {
+			t.Errorf("got errors %v want none", batch.Errs)
+		}
+	})
+}
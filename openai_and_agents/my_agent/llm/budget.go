@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrRetryBudgetExhausted is wrapped around the last failure once a shared RetryBudget ran dry
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget caps the retries of everything that shares it, on top of each caller's own limit
+// the agent puts one in the context of a Run so api retries and tool retries together can't pile up
+// first attempts are free, only retries take from the budget
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+func NewRetryBudget(total int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(total))
+	return b
+}
+
+// Take uses up one retry, false means there is none left and the caller should give up
+// a nil budget never runs out, so callers don't have to check whether there is one
+func (b *RetryBudget) Take() bool {
+	if b == nil {
+		return true
+	}
+	return b.remaining.Add(-1) >= 0
+}
+
+// Remaining is how many retries are left
+func (b *RetryBudget) Remaining() int {
+	return int(max(b.remaining.Load(), 0))
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget attaches a budget to every call made with ctx, retries then also stop when it is empty
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// RetryBudgetFrom is the budget in ctx, nil (unlimited) when there is none
+func RetryBudgetFrom(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
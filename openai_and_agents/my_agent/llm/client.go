@@ -4,25 +4,120 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 )
 
 type Client struct {
 	APIKey     string
 	BaseURL    string
 	HTTPClient *http.Client
+
+	// routes under BaseURL, see WithCompletionsPath and WithModelsPath
+	CompletionsPath string
+	ModelsPath      string
+
+	// extra headers sent with every request, see WithDefaultHeaders
+	Headers http.Header
+
+	// how many extra attempts we make on network errors, 429s and 5xx before giving up
+	MaxRetries int
+	Backoff    Backoff
+	// longest Retry-After we are willing to wait before the next attempt
+	MaxRetryAfter time.Duration
+	// told about every retry right before its wait, see WithOnRetry
+	OnRetry func(attempt int, err error, delay time.Duration)
+
+	// longest gap between two lines of a stream before it is dropped, 0 waits forever, see WithStreamIdleTimeout
+	StreamIdleTimeout time.Duration
+
+	// told about every CreateChat, see WithMetrics
+	Metrics Metrics
+
+	// request logging, nil means off, see WithLogger and WithRedactor
+	logf   func(format string, args ...any)
+	redact func(string) string
+
+	// time source for retries and timing, see WithClock
+	Clock Clock
+
+	// set by WithProxy, applied to the transport once every option has run
+	proxy *url.URL
+	// first bad option, NewClient returns it
+	optErr error
+
+	// non nil when WithDeduplication is on
+	inflight *inflightGroup
+
+	// running streams, cancelled and waited on by Close
+	streams streamGroup
+
+	// model metadata from /models, filled lazily for cost estimation
+	modelsMu sync.Mutex
+	models   map[string]ModelInfo
 }
 
-func NewClient(apikey string) *Client {
-	return &Client{
-		APIKey:     apikey,
-		BaseURL:    "https://openrouter.ai/api/v1",
-		HTTPClient: &http.Client{},
+// ClientOption tweaks the client in NewClient, same functional options pattern as the agent
+type ClientOption func(*Client)
+
+// NewClient builds a client for OpenRouter, the error is for options that can't be applied like a malformed WithProxy url
+func NewClient(apikey string, opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		APIKey:          apikey,
+		BaseURL:         "https://openrouter.ai/api/v1",
+		HTTPClient:      &http.Client{},
+		CompletionsPath: defaultCompletionsPath,
+		ModelsPath:      defaultModelsPath,
+		Headers:         make(http.Header),
+		MaxRetries:      2,
+		Backoff:         DefaultBackoff(),
+		// a minute is already longer than most callers want to block
+		MaxRetryAfter: time.Minute,
+		Metrics:       NoopMetrics{},
+		Clock:         RealClock{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.applyProxy()
+
+	if c.optErr != nil {
+		return nil, c.optErr
 	}
+	return c, nil
 }
 
+// CreateChat sends one chat request, retrying transient failures
+// the request is checked with Validate first and never sent if it is invalid
 func (c *Client) CreateChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.logRequest(req)
+
+	clock := c.clock()
+	start := clock.Now()
+
+	resp, err := c.createChat(ctx, req)
+
+	// the duration covers every retry, that is what the caller actually waited
+	var usage Usage
+	if resp != nil {
+		usage = resp.Usage
+	}
+	c.metrics().ObserveRequest(req.Model, clock.Now().Sub(start), usage, err)
+
+	return resp, err
+}
+
+func (c *Client) createChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 
 	// this is essentially converting the request to json for Marshalling
 	jsonData, err := json.Marshal(req)
@@ -30,29 +125,146 @@ func (c *Client) CreateChat(ctx context.Context, req ChatRequest) (*ChatResponse
 		return nil, fmt.Errorf("Unable to marshal Data here please check again %w", err)
 	}
 
-	// request the url with all the elements
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	key := idempotencyKeyFrom(ctx)
+	if c.inflight == nil {
+		return c.retryChat(ctx, jsonData, key)
+	}
+
+	if key == "" {
+		key = HashRequest(req)
+	}
+	// no key, no way to tell which requests are the same, so this one goes alone
+	if key == "" {
+		return c.retryChat(ctx, jsonData, key)
+	}
+	return c.inflight.do(key, func() (*ChatResponse, error) {
+		return c.retryChat(ctx, jsonData, key)
+	})
+}
+
+// retryChat is doChat wrapped in the retry loop
+func (c *Client) retryChat(ctx context.Context, jsonData []byte, idempotencyKey string) (*ChatResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if !RetryBudgetFrom(ctx).Take() {
+				return nil, fmt.Errorf("%w after %d attempts: %w", ErrRetryBudgetExhausted, attempt, lastErr)
+			}
+			delay := c.retryDelay(attempt-1, lastErr)
+			if c.OnRetry != nil {
+				c.OnRetry(attempt, lastErr, delay)
+			}
+			if err := SleepContext(ctx, c.clock(), delay); err != nil {
+				return nil, err
+			}
+		}
+
+		chatResp, err := c.doChat(ctx, jsonData, idempotencyKey)
+		if err == nil {
+			return chatResp, nil
+		}
+
+		// a cancelled or expired context will not get better by retrying
+		if !isRetryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// retryDelay is the backoff delay, unless a 429 told us to wait longer with Retry-After
+// that wait is honored up to MaxRetryAfter so a silly header can't park us for an hour
+func (c *Client) retryDelay(attempt int, lastErr error) time.Duration {
+	delay := c.Backoff.NextDelay(attempt)
+
+	var rateErr *RateLimitError
+	if errors.As(lastErr, &rateErr) && rateErr.RetryAfter > delay {
+		delay = rateErr.RetryAfter
+		if c.MaxRetryAfter > 0 {
+			delay = min(delay, c.MaxRetryAfter)
+		}
+	}
+	return delay
+}
+
+// isRetryable picks the failures worth another attempt: network blips, 429s and 5xx
+// anything else like a 401 or a bad request will fail the same way again so we dont bother
+func isRetryable(err error) bool {
+	var rateErr *RateLimitError
+	var serverErr *ServerError
+	var netErr *NetworkError
+	return errors.As(err, &rateErr) || errors.As(err, &serverErr) || errors.As(err, &netErr)
+}
+
+// Ping checks the api key and connectivity with a cheap GET /models (or ModelsPath)
+// call it on startup to fail fast instead of on the first real Run
+// returns an *AuthError for a rejected key and a *NetworkError when the api cant be reached
+func (c *Client) Ping(ctx context.Context) error {
+	httpReq, err := c.newRequest(ctx, "GET", c.modelsPath(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to create the request %w ", err)
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ping failed: %w", errorFromResponse(resp, c.clock().Now()))
+	}
+	return nil
+}
 
+// newRequest builds a request against BaseURL with the auth and content headers every call needs
+func (c *Client) newRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create the request %w ", err)
+	}
+
+	for key, values := range c.Headers {
+		httpReq.Header[key] = append([]string(nil), values...)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
 	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	return httpReq, nil
+}
+
+// doChat is a single round trip to the completions endpoint, CreateChat wraps it with the retry loop
+func (c *Client) doChat(ctx context.Context, jsonData []byte, idempotencyKey string) (*ChatResponse, error) {
+	// request the url with all the elements
+	// the body is rebuilt every attempt since a reader can only be consumed once
+	httpReq, err := c.newRequest(ctx, "POST", c.completionsPath(), jsonData)
+	if err != nil {
+		return nil, err
+	}
+	// same key on every retry so the provider can tell it is the same request
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 
 	resp, err := c.HTTPClient.Do(httpReq)
 
 	if err != nil {
-		return nil, fmt.Errorf("Unable to fetch response check your API %w ", err)
-
+		return nil, &NetworkError{Err: err}
 	}
 
 	defer resp.Body.Close() // close the flowing pipe you just stareted
 
 	if resp.StatusCode != http.StatusOK {
-		// this is good practice Read the error body to see why failed (optional but good practice)
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-
+		// this is good practice Read the error body to see why failed, the typed error carries the api's message
+		return nil, errorFromResponse(resp, c.clock().Now())
 	}
 
 	var chatResp ChatResponse
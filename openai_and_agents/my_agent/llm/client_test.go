@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient points a client at a fake server
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient("test-key")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+	return client
+}
+
+func TestClient_Ping(t *testing.T) {
+	t.Run("bad key is an auth error", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		err := client.Ping(context.Background())
+
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("got %v, want an *AuthError", err)
+		}
+		if authErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("got status %d want %d", authErr.StatusCode, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("healthy api", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer test-key" {
+				t.Errorf("got %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`{"data":[]}`))
+		})
+
+		if err := client.Ping(context.Background()); err != nil {
+			t.Errorf("got %v want nil", err)
+		}
+	})
+
+	t.Run("unreachable api is a network error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		client, err := NewClient("test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.BaseURL = server.URL
+
+		var netErr *NetworkError
+		if err := client.Ping(context.Background()); !errors.As(err, &netErr) {
+			t.Errorf("got %v, want a *NetworkError", err)
+		}
+	})
+}
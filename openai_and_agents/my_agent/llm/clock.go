@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is where the retry and backoff code gets its time from
+// the real one by default, tests swap in a fake one so nothing actually sleeps
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// RealClock is the wall clock, just the time package behind the interface
+type RealClock struct{}
+
+func (RealClock) Now() time.Time                         { return time.Now() }
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (RealClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+// WithClock replaces the clock the client uses for retry waits, Retry-After and request timing
+func WithClock(clock Clock) ClientOption {
+	return func(c *Client) {
+		c.Clock = clock
+	}
+}
+
+// SleepContext waits for d on clock but wakes up early if the context is done
+// exported so the tools package can wait on the same clock
+func SleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	if clock == nil {
+		clock = RealClock{}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
+
+// clock falls back to the real one for clients built without NewClient
+func (c *Client) clock() Clock {
+	if c.Clock == nil {
+		return RealClock{}
+	}
+	return c.Clock
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock never really waits, every After or Sleep is recorded and moves the clock forward at once
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.Sleep(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- f.Now()
+	return ch
+}
+
+func (f *fakeClock) Sleep(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waits = append(f.waits, d)
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeClock) Waits() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.waits...)
+}
+
+func TestClient_RetryWithFakeClock(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	})
+	// the default backoff would really wait 0.5s and then 1s
+	client.Backoff = Backoff{BaseDelay: 500 * time.Millisecond, Multiplier: 2, MaxDelay: 30 * time.Second}
+	clock := newFakeClock()
+	client.Clock = clock
+
+	start := time.Now()
+	_, err := client.CreateChat(context.Background(), ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}})
+	if err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	if got, want := clock.Waits(), []time.Duration{500 * time.Millisecond, time.Second}; !slices.Equal(got, want) {
+		t.Errorf("got waits %v want %v", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("took %v, the fake clock should not really sleep", elapsed)
+	}
+}
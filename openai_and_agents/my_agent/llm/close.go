@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned by streams started after Close
+var ErrClientClosed = errors.New("llm: client is closed")
+
+// streamGroup keeps track of the running CreateChatStream goroutines so Close can cancel and wait for them
+// the zero value is ready to use, which matters since a Client can be built without NewClient
+type streamGroup struct {
+	mu      sync.Mutex
+	closed  bool
+	next    int
+	cancels map[int]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// add registers a new stream, the returned context is cancelled by close
+// done has to be called once the stream goroutine is finished
+func (g *streamGroup) add(ctx context.Context) (context.Context, func(), error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return nil, nil, ErrClientClosed
+	}
+	if g.cancels == nil {
+		g.cancels = make(map[int]context.CancelFunc)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	id := g.next
+	g.next++
+	g.cancels[id] = cancel
+	g.wg.Add(1)
+
+	done := func() {
+		g.mu.Lock()
+		delete(g.cancels, id)
+		g.mu.Unlock()
+		cancel()
+		g.wg.Done()
+	}
+	return ctx, done, nil
+}
+
+func (g *streamGroup) close() {
+	g.mu.Lock()
+	g.closed = true
+	for _, cancel := range g.cancels {
+		cancel()
+	}
+	g.mu.Unlock()
+
+	// outside the lock, done needs it to unregister
+	g.wg.Wait()
+}
+
+// Close cancels every in flight CreateChatStream and returns once all of them have closed their channels
+// meant for server shutdown so no stream goroutine outlives the client
+// streams started after Close fail right away with ErrClientClosed, CreateChat is not affected
+func (c *Client) Close() error {
+	c.streams.close()
+	return nil
+}
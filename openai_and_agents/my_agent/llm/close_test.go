@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_Close(t *testing.T) {
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	// one chunk and then nothing until the client hangs up, like a slow model
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	tokens, errs := client.CreateChatStream(context.Background(), req)
+	if got := <-tokens; got != "Hel" {
+		t.Fatalf("got first token %q want %q", got, "Hel")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return, the stream was not drained")
+	}
+
+	// Close only returns once the goroutine is done so both channels are closed by now
+	select {
+	case _, ok := <-tokens:
+		if ok {
+			t.Error("got another token after Close")
+		}
+	default:
+		t.Error("tokens channel is still open after Close")
+	}
+
+	var streamErr *StreamError
+	if err := <-errs; !errors.As(err, &streamErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v want a *StreamError wrapping context.Canceled", err)
+	}
+
+	t.Run("new streams fail after Close", func(t *testing.T) {
+		_, err := drain(client.CreateChatStream(context.Background(), req))
+		if !errors.Is(err, ErrClientClosed) {
+			t.Errorf("got %v want ErrClientClosed", err)
+		}
+	})
+}
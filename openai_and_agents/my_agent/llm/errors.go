@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the api errors below are what CreateChat, CreateChatStream, Ping and ListModels return for a failed call
+// branch on them with errors.As, the retry loop does the same to decide what is worth another attempt
+
+// AuthError means the api key was rejected, retrying will never fix it
+type AuthError struct {
+	StatusCode int
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed (status %d): check your API key", e.StatusCode)
+}
+
+// RateLimitError is a 429, RetryAfter is what the Retry-After header asked for (0 when there was none)
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Message    string
+}
+
+func (e *RateLimitError) Error() string {
+	msg := "rate limited (status 429)"
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// ServerError is any 5xx, the provider (or the model behind it) had a problem, usually worth a retry
+type ServerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return withMessage(fmt.Sprintf("server error (status %d)", e.StatusCode), e.Message)
+}
+
+// BadRequestError is a 400, Message is the reason the api gave for rejecting the request
+type BadRequestError struct {
+	Message string
+}
+
+func (e *BadRequestError) Error() string {
+	return withMessage("bad request (status 400)", e.Message)
+}
+
+// NetworkError means we never got an http response back (dns, refused connection, timeout ...)
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+func withMessage(prefix, message string) string {
+	if message == "" {
+		return prefix
+	}
+	return prefix + ": " + message
+}
+
+// errorFromResponse turns a non 200 response into one of the typed errors above
+// statuses without a type of their own stay a plain "unexpected status code" error
+// now is needed for a Retry-After given as a date
+func errorFromResponse(resp *http.Response, now time.Time) error {
+	message := apiErrorMessage(resp.Body)
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return &AuthError{StatusCode: code}
+	case code == http.StatusTooManyRequests:
+		return &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now), Message: message}
+	case code == http.StatusBadRequest:
+		return &BadRequestError{Message: message}
+	case code >= 500:
+		return &ServerError{StatusCode: code, Message: message}
+	}
+	return fmt.Errorf("%s", withMessage(fmt.Sprintf("unexpected status code: %d", resp.StatusCode), message))
+}
+
+// apiErrorMessage pulls the message out of an OpenAI style {"error": {"message": ...}} body
+// anything else is used as is (trimmed and capped), an empty body gives an empty message
+func apiErrorMessage(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 64*1024))
+
+	var parsed struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &parsed) == nil && parsed.Error.Message != "" {
+		return parsed.Error.Message
+	}
+
+	text := strings.TrimSpace(string(data))
+	if len(text) > 200 {
+		text = text[:200] + "..."
+	}
+	return text
+}
+
+// parseRetryAfter reads Retry-After in either form, delay-seconds ("2") or an http date
+// 0 when missing, unreadable or already in the past
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
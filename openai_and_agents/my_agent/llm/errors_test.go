@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_CreateChatErrorTypes(t *testing.T) {
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	respond := func(status int, header, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if header != "" {
+				w.Header().Set("Retry-After", header)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}
+	}
+
+	t.Run("401 is an AuthError", func(t *testing.T) {
+		client := newTestClient(t, respond(http.StatusUnauthorized, "", ""))
+
+		var authErr *AuthError
+		if _, err := client.CreateChat(context.Background(), req); !errors.As(err, &authErr) {
+			t.Errorf("got %v want an *AuthError", err)
+		}
+	})
+
+	t.Run("429 is a RateLimitError", func(t *testing.T) {
+		client := newTestClient(t, respond(http.StatusTooManyRequests, "7", `{"error":{"message":"slow down"}}`))
+		client.MaxRetries = 0
+
+		_, err := client.CreateChat(context.Background(), req)
+
+		var rateErr *RateLimitError
+		if !errors.As(err, &rateErr) {
+			t.Fatalf("got %v want a *RateLimitError", err)
+		}
+		if rateErr.RetryAfter != 7*time.Second || rateErr.Message != "slow down" {
+			t.Errorf("got retry after %v message %q want 7s and %q", rateErr.RetryAfter, rateErr.Message, "slow down")
+		}
+	})
+
+	t.Run("5xx is a ServerError", func(t *testing.T) {
+		client := newTestClient(t, respond(http.StatusBadGateway, "", "upstream died"))
+		client.MaxRetries = 0
+
+		var serverErr *ServerError
+		_, err := client.CreateChat(context.Background(), req)
+		if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusBadGateway {
+			t.Fatalf("got %v want a *ServerError with status 502", err)
+		}
+		if serverErr.Message != "upstream died" {
+			t.Errorf("got message %q want the plain body", serverErr.Message)
+		}
+	})
+
+	t.Run("400 is a BadRequestError with the api message", func(t *testing.T) {
+		client := newTestClient(t, respond(http.StatusBadRequest, "", `{"error":{"message":"model not found","code":400}}`))
+
+		var badErr *BadRequestError
+		_, err := client.CreateChat(context.Background(), req)
+		if !errors.As(err, &badErr) || badErr.Message != "model not found" {
+			t.Errorf("got %v want a *BadRequestError with message %q", err, "model not found")
+		}
+	})
+
+	t.Run("transport failures are a NetworkError", func(t *testing.T) {
+		client, err := NewClient("test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.BaseURL = "http://127.0.0.1:1"
+		client.MaxRetries = 0
+
+		var netErr *NetworkError
+		if _, err := client.CreateChat(context.Background(), req); !errors.As(err, &netErr) {
+			t.Errorf("got %v want a *NetworkError", err)
+		}
+	})
+}
+
+func TestClient_CreateChatRetriesByType(t *testing.T) {
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	// statuses answered in order, after that a success
+	run := func(statuses ...int) (int, error) {
+		calls := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= len(statuses) {
+				w.WriteHeader(statuses[calls-1])
+				return
+			}
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+		})
+		client.Backoff = Backoff{BaseDelay: time.Millisecond, Multiplier: 1}
+		client.Clock = newFakeClock()
+
+		_, err := client.CreateChat(context.Background(), req)
+		return calls, err
+	}
+
+	if calls, err := run(http.StatusServiceUnavailable, http.StatusTooManyRequests); err != nil || calls != 3 {
+		t.Errorf("got %d calls and %v, want 503 and 429 retried and then success", calls, err)
+	}
+	if calls, _ := run(http.StatusBadRequest); calls != 1 {
+		t.Errorf("got %d calls, a 400 should not be retried", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Duration{
+		"2":                             2 * time.Second,
+		" 30 ":                          30 * time.Second,
+		"Wed, 01 Jan 2025 12:00:05 GMT": 5 * time.Second,
+		"Wed, 01 Jan 2025 11:00:00 GMT": 0, // already passed
+		"":                              0,
+		"soon":                          0,
+		"-3":                            0,
+	}
+	for value, want := range cases {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q): got %v want %v", value, got, want)
+		}
+	}
+}
+
+func TestClient_HonorsRetryAfter(t *testing.T) {
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	newClient := func(retryAfter string) (*Client, *fakeClock) {
+		calls := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+		})
+		client.Backoff = Backoff{BaseDelay: 10 * time.Millisecond, Multiplier: 2}
+
+		// nothing really sleeps, the fake clock just remembers every wait
+		clock := newFakeClock()
+		client.Clock = clock
+		return client, clock
+	}
+
+	t.Run("waits at least Retry-After", func(t *testing.T) {
+		client, clock := newClient("2")
+
+		if _, err := client.CreateChat(context.Background(), req); err != nil {
+			t.Fatalf("CreateChat failed: %v", err)
+		}
+		if waits := clock.Waits(); len(waits) != 1 || waits[0] < 2*time.Second {
+			t.Errorf("got waits %v want one wait of at least 2s", waits)
+		}
+	})
+
+	t.Run("capped by MaxRetryAfter", func(t *testing.T) {
+		client, clock := newClient("3600")
+		client.MaxRetryAfter = 5 * time.Second
+
+		client.CreateChat(context.Background(), req)
+		if waits := clock.Waits(); len(waits) != 1 || waits[0] != 5*time.Second {
+			t.Errorf("got waits %v want one wait of 5s", waits)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// HashRequest is a stable sha256 fingerprint of the request, for caching, deduplication and the like
+// the request is canonicalized through json: struct fields always come out in declaration order
+// and json.Marshal writes map keys sorted, so LogitBias, tool schemas and any other maps hash the same
+// no matter what order they were filled in
+// a request json can't encode at all (a NaN or Inf temperature ...) gets "", callers skip whatever the key was for
+func HashRequest(req ChatRequest) string {
+	data, err := json.Marshal(req)
+	if err != nil {
+		// ToolChoice is an interface{} so it is the usual suspect, try again without it
+		// anything else (NaN, Inf) fails the second time too and then there is nothing to hash
+		req.ToolChoice = nil
+		if data, err = json.Marshal(req); err != nil {
+			return ""
+		}
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHashRequest(t *testing.T) {
+	build := func(biasKeys []string, props []string) ChatRequest {
+		bias := make(map[string]int)
+		for _, key := range biasKeys {
+			bias[key] = len(key)
+		}
+		properties := make(map[string]any)
+		for _, prop := range props {
+			properties[prop] = map[string]any{"type": "string"}
+		}
+
+		return ChatRequest{
+			Model:     "test-model",
+			Messages:  []Message{NewUserMessage("hi")},
+			LogitBias: bias,
+			Tools: []Tool{{Type: "function", Function: FunctionDescription{
+				Name:       "lookup",
+				Parameters: map[string]any{"type": "object", "properties": properties},
+			}}},
+		}
+	}
+
+	a := build([]string{"1234", "42", "7"}, []string{"city", "country", "zip"})
+	b := build([]string{"7", "1234", "42"}, []string{"zip", "city", "country"})
+
+	if HashRequest(a) != HashRequest(b) {
+		t.Error("equal requests with differently ordered maps hashed differently")
+	}
+	if got := len(HashRequest(a)); got != 64 {
+		t.Errorf("got a %d character hash want 64 (sha256 hex)", got)
+	}
+
+	c := build([]string{"7", "1234", "42"}, []string{"zip", "city"})
+	if HashRequest(a) == HashRequest(c) {
+		t.Error("different requests hashed the same")
+	}
+}
+
+func TestHashRequest_Unencodable(t *testing.T) {
+	t.Run("a bad tool choice still hashes", func(t *testing.T) {
+		req := ChatRequest{Model: "test-model", ToolChoice: make(chan int)}
+		if HashRequest(req) == "" {
+			t.Error("got an empty hash, want the request hashed without its tool choice")
+		}
+	})
+
+	t.Run("a NaN temperature gets no hash", func(t *testing.T) {
+		a := ChatRequest{Model: "a", Temperature: math.NaN()}
+		b := ChatRequest{Model: "b", Temperature: math.Inf(1)}
+		if got := HashRequest(a); got != "" {
+			t.Errorf("got %q want an empty hash", got)
+		}
+		if got := HashRequest(b); got != "" {
+			t.Errorf("got %q want an empty hash", got)
+		}
+	})
+}
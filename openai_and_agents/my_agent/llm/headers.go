@@ -0,0 +1,29 @@
+package llm
+
+// WithDefaultHeaders adds headers to every request the client sends
+// auth and content type are always set by the client itself and win over these
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		for key, value := range headers {
+			c.Headers.Set(key, value)
+		}
+	}
+}
+
+// WithReferer sets HTTP-Referer, OpenRouter uses it to attribute requests to your site
+func WithReferer(url string) ClientOption {
+	return WithDefaultHeaders(map[string]string{"HTTP-Referer": url})
+}
+
+// WithTitle sets X-Title, the app name shown in the OpenRouter rankings and analytics
+func WithTitle(name string) ClientOption {
+	return WithDefaultHeaders(map[string]string{"X-Title": name})
+}
+
+// WithApp sets both OpenRouter analytics headers at once since they almost always go together
+func WithApp(name, url string) ClientOption {
+	return WithDefaultHeaders(map[string]string{
+		"X-Title":      name,
+		"HTTP-Referer": url,
+	})
+}
@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestClient_WithApp(t *testing.T) {
+	var got http.Header
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	})
+	WithApp("Carol Sturka", "https://example.com")(client)
+
+	if _, err := client.CreateChat(context.Background(), ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	if got.Get("X-Title") != "Carol Sturka" {
+		t.Errorf("got X-Title %q", got.Get("X-Title"))
+	}
+	if got.Get("HTTP-Referer") != "https://example.com" {
+		t.Errorf("got HTTP-Referer %q", got.Get("HTTP-Referer"))
+	}
+	if got.Get("Authorization") != "Bearer test-key" {
+		t.Errorf("got Authorization %q, the default headers should not touch auth", got.Get("Authorization"))
+	}
+}
+
+func TestClient_DefaultHeadersCannotOverrideAuth(t *testing.T) {
+	client, err := NewClient("real-key", WithDefaultHeaders(map[string]string{"Authorization": "Bearer nope"}), WithTitle("app"))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	req, err := client.newRequest(context.Background(), "GET", "/models", nil)
+	if err != nil {
+		t.Fatalf("newRequest failed: %v", err)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer real-key" {
+		t.Errorf("got Authorization %q", got)
+	}
+	if got := req.Header.Get("X-Title"); got != "app" {
+		t.Errorf("got X-Title %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey attaches a key to one call, it is sent as the Idempotency-Key header
+// and with deduplication on, concurrent calls with the same key share a single request
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// WithDeduplication turns on single flight: while a request is in flight, identical ones wait for it
+// and get its response instead of paying for the same generation twice
+// requests without an explicit key are matched on a hash of the whole request
+// the shared request runs with the first caller's context, if that caller cancels the waiters get the error too
+func WithDeduplication() ClientOption {
+	return func(c *Client) {
+		c.inflight = &inflightGroup{calls: make(map[string]*inflightCall)}
+	}
+}
+
+// inflightGroup is a minimal singleflight, the first caller for a key does the work
+// and everyone arriving while it runs waits on done and reads the same result
+type inflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+type inflightCall struct {
+	done chan struct{}
+	resp *ChatResponse
+	err  error
+}
+
+func (g *inflightGroup) do(key string, fn func() (*ChatResponse, error)) (*ChatResponse, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return copyResponse(call.resp), call.err
+	}
+
+	call := &inflightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.resp, call.err = fn()
+
+	// forget the key once done, this is about in flight duplicates not a cache
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+
+	return copyResponse(call.resp), call.err
+}
+
+// every waiter gets its own top level copy so nobody can clobber anybody else's fields
+func copyResponse(resp *ChatResponse) *ChatResponse {
+	if resp == nil {
+		return nil
+	}
+	cp := *resp
+	return &cp
+}
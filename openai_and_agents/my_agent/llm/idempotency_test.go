@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Deduplication(t *testing.T) {
+	var hits atomic.Int32
+	var gotKey atomic.Value
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		gotKey.Store(r.Header.Get("Idempotency-Key"))
+		// stay in flight long enough for the duplicate to arrive
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"expensive answer"}}]}`))
+	})
+	WithDeduplication()(client)
+
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("write me a novel")}}
+
+	var wg sync.WaitGroup
+	replies := make([]string, 2)
+	for i := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.CreateChat(context.Background(), req)
+			if err != nil {
+				t.Errorf("CreateChat failed: %v", err)
+				return
+			}
+			replies[i] = resp.Choices[0].Message.Content
+		}()
+	}
+	wg.Wait()
+
+	if hits.Load() != 1 {
+		t.Errorf("got %d requests at the server want 1", hits.Load())
+	}
+	if replies[0] != "expensive answer" || replies[1] != "expensive answer" {
+		t.Errorf("got replies %q, want both callers to get the answer", replies)
+	}
+	if key, _ := gotKey.Load().(string); key != HashRequest(req) {
+		t.Errorf("got Idempotency-Key %q, want the request hash", key)
+	}
+}
+
+func TestClient_ExplicitIdempotencyKey(t *testing.T) {
+	var gotKey string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("Idempotency-Key")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	})
+
+	ctx := WithIdempotencyKey(context.Background(), "order-42")
+	if _, err := client.CreateChat(ctx, ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+	if gotKey != "order-42" {
+		t.Errorf("got Idempotency-Key %q want %q", gotKey, "order-42")
+	}
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"log"
+	"regexp"
+)
+
+// WithLogger logs every chat request (model and messages) before it is sent, off by default
+// message content goes through the redactor first, DefaultRedactor unless WithRedactor says otherwise
+func WithLogger(logger *log.Logger) ClientOption {
+	return func(c *Client) {
+		c.logf = logger.Printf
+	}
+}
+
+// WithRedactor changes what message content looks like in the logs, the request that is sent is never touched
+// pass func(s string) string { return s } to log content as is
+func WithRedactor(redact func(string) string) ClientOption {
+	return func(c *Client) {
+		c.redact = redact
+	}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// sk-... style api keys (openai, openrouter, anthropic ...) and bearer tokens
+	apiKeyPattern = regexp.MustCompile(`\b(sk|pk|rk)-[A-Za-z0-9_-]{16,}|(?i:bearer\s+)[A-Za-z0-9._~+/-]{16,}=*`)
+)
+
+// DefaultRedactor masks email addresses and things that look like api keys or bearer tokens
+// it is pattern based, good against accidents, not a guarantee that nothing sensitive gets through
+func DefaultRedactor(s string) string {
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	return apiKeyPattern.ReplaceAllString(s, "[secret]")
+}
+
+// logRequest writes one line per message, a no-op without WithLogger
+func (c *Client) logRequest(req ChatRequest) {
+	if c.logf == nil {
+		return
+	}
+
+	redact := c.redact
+	if redact == nil {
+		redact = DefaultRedactor
+	}
+
+	c.logf("llm: request to %s with %d messages", req.Model, len(req.Messages))
+	for _, msg := range req.Messages {
+		c.logf("llm:   %s: %s", msg.Role, redact(msg.Content))
+	}
+}
@@ -1,6 +1,10 @@
 package llm
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
 
 func NewSystemMessage(content string) Message {
 
@@ -18,6 +22,27 @@ func NewUserMessage(content string) Message {
 	}
 }
 
+// names have to match this on the api side, letters, digits, _ and - only
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// NewNamedUserMessage is a user message labelled with who said it, for group chats with several participants
+// the name can't have spaces or other punctuation, providers reject those
+func NewNamedUserMessage(name, content string) (Message, error) {
+	if err := validateName(name); err != nil {
+		return Message{}, err
+	}
+	msg := NewUserMessage(content)
+	msg.Name = name
+	return msg, nil
+}
+
+func validateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid message name %q: use 1-64 letters, digits, _ or -", name)
+	}
+	return nil
+}
+
 // Standard reply
 func NewAssistantMessage(content string) Message {
 	return Message{
@@ -45,6 +70,15 @@ func NewToolResult(toolCallID string, output string) Message {
 	}
 }
 
+// same as NewToolResult but for tools that produce structured data, v is marshalled to json for the content
+func NewToolResultJSON(toolCallID string, v any) (Message, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Message{}, fmt.Errorf("unable to marshal tool result: %w", err)
+	}
+	return NewToolResult(toolCallID, string(data)), nil
+}
+
 func NewToolError(toolCallID string, err error) Message {
 	return Message{
 		Role:       "tool",
@@ -52,3 +86,17 @@ func NewToolError(toolCallID string, err error) Message {
 		Content:    fmt.Sprintf("Error executing tool: %v. Please fix your arguments.", err),
 	}
 }
+
+// role predicates, so the loop code reads as what it means instead of comparing strings
+
+// IsToolCall is an assistant turn asking for tools (it may carry some text as well)
+func (m Message) IsToolCall() bool { return len(m.ToolCalls) > 0 }
+
+// IsToolResult is the answer to a tool call, see NewToolResult
+func (m Message) IsToolResult() bool { return m.Role == "tool" }
+
+// IsAssistant is any assistant turn, plain text or tool call
+func (m Message) IsAssistant() bool { return m.Role == "assistant" }
+
+func (m Message) IsUser() bool   { return m.Role == "user" }
+func (m Message) IsSystem() bool { return m.Role == "system" }
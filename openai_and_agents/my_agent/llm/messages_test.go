@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewToolResultJSON(t *testing.T) {
+	type forecast struct {
+		City  string   `json:"city"`
+		TempC float64  `json:"temp_c"`
+		Days  []string `json:"days"`
+	}
+
+	want := forecast{City: "Paris", TempC: 21.5, Days: []string{"mon", "tue"}}
+
+	msg, err := NewToolResultJSON("call_1", want)
+	if err != nil {
+		t.Fatalf("NewToolResultJSON failed: %v", err)
+	}
+
+	if msg.Role != "tool" || msg.ToolCallID != "call_1" {
+		t.Errorf("got role %q id %q, want a tool result for call_1", msg.Role, msg.ToolCallID)
+	}
+
+	// the content should decode straight back into the same struct
+	var got forecast
+	if err := json.Unmarshal([]byte(msg.Content), &got); err != nil {
+		t.Fatalf("content is not valid json: %v", err)
+	}
+	if got.City != want.City || got.TempC != want.TempC || len(got.Days) != 2 {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+
+	t.Run("unmarshalable value", func(t *testing.T) {
+		if _, err := NewToolResultJSON("call_2", make(chan int)); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func TestMessage_Predicates(t *testing.T) {
+	call := ToolCall{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_time"}}
+	withText := NewToolCallMessage([]ToolCall{call})
+	withText.Content = "let me check"
+
+	cases := []struct {
+		name                                          string
+		msg                                           Message
+		toolCall, toolResult, assistant, user, system bool
+	}{
+		{"system", NewSystemMessage("be nice"), false, false, false, false, true},
+		{"user", NewUserMessage("hi"), false, false, false, true, false},
+		{"assistant text", NewAssistantMessage("hello"), false, false, true, false, false},
+		{"tool call", NewToolCallMessage([]ToolCall{call}), true, false, true, false, false},
+		{"tool call with text", withText, true, false, true, false, false},
+		{"tool result", NewToolResult("call_1", "noon"), false, true, false, false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.msg
+			got := []bool{m.IsToolCall(), m.IsToolResult(), m.IsAssistant(), m.IsUser(), m.IsSystem()}
+			want := []bool{tc.toolCall, tc.toolResult, tc.assistant, tc.user, tc.system}
+			for i, name := range []string{"IsToolCall", "IsToolResult", "IsAssistant", "IsUser", "IsSystem"} {
+				if got[i] != want[i] {
+					t.Errorf("%s: got %v want %v", name, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewNamedUserMessage(t *testing.T) {
+	msg, err := NewNamedUserMessage("alice_01", "I vote for pizza")
+	if err != nil {
+		t.Fatalf("NewNamedUserMessage failed: %v", err)
+	}
+
+	data, _ := json.Marshal(msg)
+	if want := `{"role":"user","content":"I vote for pizza","name":"alice_01"}`; string(data) != want {
+		t.Errorf("got %s want %s", data, want)
+	}
+
+	for _, bad := range []string{"", "alice smith", "bob!", strings.Repeat("x", 65)} {
+		if _, err := NewNamedUserMessage(bad, "hi"); err == nil {
+			t.Errorf("name %q: expected an error but didn't get one", bad)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics is told about every finished CreateChat call, err is nil on success
+// implementations get called from whatever goroutine made the request so they must be safe for concurrent use
+type Metrics interface {
+	ObserveRequest(model string, dur time.Duration, usage Usage, err error)
+}
+
+// WithMetrics plugs a metrics hook into the client
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.Metrics = m
+	}
+}
+
+// NoopMetrics is the default, it throws everything away
+type NoopMetrics struct{}
+
+func (NoopMetrics) ObserveRequest(string, time.Duration, Usage, error) {}
+
+// metrics falls back to NoopMetrics for clients built without NewClient or given WithMetrics(nil)
+func (c *Client) metrics() Metrics {
+	if c.Metrics == nil {
+		return NoopMetrics{}
+	}
+	return c.Metrics
+}
+
+// PrometheusMetrics is a tiny dependency free adapter that keeps the numbers in memory
+// and serves them in the prometheus text format, mount it on /metrics and point prometheus at it
+//
+//	metrics := llm.NewPrometheusMetrics()
+//	client, err := llm.NewClient(key, llm.WithMetrics(metrics))
+//	http.Handle("/metrics", metrics)
+type PrometheusMetrics struct {
+	mu       sync.Mutex
+	requests map[[2]string]int     // {model, status} -> count
+	tokens   map[[2]string]int     // {model, prompt|completion} -> count
+	duration map[string]*histogram // model -> latency histogram
+}
+
+type histogram struct {
+	counts []int // one per bucket, cumulative like prometheus expects
+	sum    float64
+	count  int
+}
+
+// latency buckets in seconds, llm calls are slow so these go well past the usual http ones
+var durationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		requests: make(map[[2]string]int),
+		tokens:   make(map[[2]string]int),
+		duration: make(map[string]*histogram),
+	}
+}
+
+func (p *PrometheusMetrics) ObserveRequest(model string, dur time.Duration, usage Usage, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests[[2]string{model, statusLabel(err)}]++
+	p.tokens[[2]string{model, "prompt"}] += usage.PromptTokens
+	p.tokens[[2]string{model, "completion"}] += usage.CompletionTokens
+
+	h, ok := p.duration[model]
+	if !ok {
+		h = &histogram{counts: make([]int, len(durationBuckets))}
+		p.duration[model] = h
+	}
+	seconds := dur.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// statusLabel buckets an error into something low cardinality for the requests counter
+func statusLabel(err error) string {
+	var authErr *AuthError
+	var rateErr *RateLimitError
+	var serverErr *ServerError
+	var badErr *BadRequestError
+	var netErr *NetworkError
+
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &authErr):
+		return fmt.Sprint(authErr.StatusCode)
+	case errors.As(err, &rateErr):
+		return "429"
+	case errors.As(err, &serverErr):
+		return fmt.Sprint(serverErr.StatusCode)
+	case errors.As(err, &badErr):
+		return "400"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+	return "error"
+}
+
+// ServeHTTP writes the prometheus text exposition format
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	p.WriteTo(w)
+}
+
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("# TYPE llm_requests_total counter\n")
+	for _, key := range sortedKeys(p.requests) {
+		fmt.Fprintf(&b, "llm_requests_total{model=%q,status=%q} %d\n", key[0], key[1], p.requests[key])
+	}
+
+	b.WriteString("# TYPE llm_tokens_total counter\n")
+	for _, key := range sortedKeys(p.tokens) {
+		fmt.Fprintf(&b, "llm_tokens_total{model=%q,kind=%q} %d\n", key[0], key[1], p.tokens[key])
+	}
+
+	b.WriteString("# TYPE llm_request_duration_seconds histogram\n")
+	models := make([]string, 0, len(p.duration))
+	for model := range p.duration {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		h := p.duration[model]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&b, "llm_request_duration_seconds_bucket{model=%q,le=\"%g\"} %d\n", model, bound, h.counts[i])
+		}
+		fmt.Fprintf(&b, "llm_request_duration_seconds_bucket{model=%q,le=\"+Inf\"} %d\n", model, h.count)
+		fmt.Fprintf(&b, "llm_request_duration_seconds_sum{model=%q} %g\n", model, h.sum)
+		fmt.Fprintf(&b, "llm_request_duration_seconds_count{model=%q} %d\n", model, h.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func sortedKeys(m map[[2]string]int) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// spyMetrics remembers every observation
+type spyMetrics struct {
+	mu    sync.Mutex
+	calls []observation
+}
+
+type observation struct {
+	model string
+	dur   time.Duration
+	usage Usage
+	err   error
+}
+
+func (s *spyMetrics) ObserveRequest(model string, dur time.Duration, usage Usage, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, observation{model, dur, usage, err})
+}
+
+func TestClient_Metrics(t *testing.T) {
+	spy := &spyMetrics{}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":7,"completion_tokens":3,"total_tokens":10}}`))
+	})
+	WithMetrics(spy)(client)
+
+	if _, err := client.CreateChat(context.Background(), ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+
+	if len(spy.calls) != 1 {
+		t.Fatalf("got %d observations want 1", len(spy.calls))
+	}
+	got := spy.calls[0]
+
+	if got.model != "test-model" || got.err != nil {
+		t.Errorf("got model %q err %v", got.model, got.err)
+	}
+	if got.dur < 20*time.Millisecond {
+		t.Errorf("got duration %v, want at least the 20ms the server took", got.dur)
+	}
+	if want := (Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}); got.usage != want {
+		t.Errorf("got usage %+v want %+v", got.usage, want)
+	}
+}
+
+func TestClient_NilMetrics(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	})
+	WithMetrics(nil)(client)
+
+	// must not panic, a nil Metrics is the same as NoopMetrics
+	if _, err := client.CreateChat(context.Background(), ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	metrics.ObserveRequest("test-model", 300*time.Millisecond, Usage{PromptTokens: 7, CompletionTokens: 3}, nil)
+	metrics.ObserveRequest("test-model", time.Second, Usage{}, &AuthError{StatusCode: 401})
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	got := b.String()
+
+	for _, want := range []string{
+		`llm_requests_total{model="test-model",status="ok"} 1`,
+		`llm_requests_total{model="test-model",status="401"} 1`,
+		`llm_tokens_total{model="test-model",kind="prompt"} 7`,
+		`llm_request_duration_seconds_bucket{model="test-model",le="0.5"} 1`,
+		`llm_request_duration_seconds_count{model="test-model"} 2`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ModelInfo is one entry of GET /models, only the bits we actually use
+type ModelInfo struct {
+	ID            string       `json:"id"`
+	Name          string       `json:"name"`
+	ContextLength int          `json:"context_length"`
+	Pricing       ModelPricing `json:"pricing"`
+
+	// request parameters the model accepts, "tools" among them means it can do function calling
+	SupportedParameters []string `json:"supported_parameters,omitempty"`
+}
+
+// SupportsTools reports whether the model accepts tool definitions
+// known is false when /models said nothing about supported parameters, callers should not block on that
+func (m ModelInfo) SupportsTools() (supported, known bool) {
+	if len(m.SupportedParameters) == 0 {
+		return false, false
+	}
+	return slices.Contains(m.SupportedParameters, "tools"), true
+}
+
+// ModelPricing is USD per token, OpenRouter sends them as strings like "0.0000025"
+type ModelPricing struct {
+	Prompt     string `json:"prompt"`
+	Completion string `json:"completion"`
+}
+
+type modelsResponse struct {
+	Data []ModelInfo `json:"data"`
+}
+
+// ListModels fetches every model the api knows about and refreshes the pricing cache with them
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	httpReq, err := c.newRequest(ctx, "GET", c.modelsPath(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorFromResponse(resp, c.clock().Now())
+	}
+
+	var models modelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
+		return nil, fmt.Errorf("error decoding models: %w", err)
+	}
+
+	c.modelsMu.Lock()
+	defer c.modelsMu.Unlock()
+	if c.models == nil {
+		c.models = make(map[string]ModelInfo)
+	}
+	for _, m := range models.Data {
+		c.models[m.ID] = m
+	}
+
+	return models.Data, nil
+}
+
+// SetModelPricing fills the pricing cache by hand, for gateways without a /models endpoint (and for tests)
+func (c *Client) SetModelPricing(model string, pricing ModelPricing) {
+	c.modelsMu.Lock()
+	defer c.modelsMu.Unlock()
+
+	if c.models == nil {
+		c.models = make(map[string]ModelInfo)
+	}
+	info := c.models[model]
+	info.ID = model
+	info.Pricing = pricing
+	c.models[model] = info
+}
+
+// SetModelInfo puts one model's metadata into the cache by hand, a static allowlist for gateways without /models
+func (c *Client) SetModelInfo(info ModelInfo) {
+	c.modelsMu.Lock()
+	defer c.modelsMu.Unlock()
+
+	if c.models == nil {
+		c.models = make(map[string]ModelInfo)
+	}
+	c.models[info.ID] = info
+}
+
+// SupportsTools looks the model up in the metadata cache, it never calls the api itself
+// so call ListModels (or SetModelInfo) first, until then every model is unknown
+func (c *Client) SupportsTools(model string) (supported, known bool) {
+	info, ok := c.cachedModel(model)
+	if !ok {
+		return false, false
+	}
+	return info.SupportsTools()
+}
+
+// ContextLength is the model's context window in tokens from the metadata cache, like SupportsTools it never fetches
+// false when the model is unknown or /models didn't say
+func (c *Client) ContextLength(model string) (int, bool) {
+	info, ok := c.cachedModel(model)
+	if !ok || info.ContextLength <= 0 {
+		return 0, false
+	}
+	return info.ContextLength, true
+}
+
+// EstimateCost turns the token counts of one call into dollars
+// the pricing is fetched from /models once and cached on the client
+func (c *Client) EstimateCost(model string, usage Usage) (float64, error) {
+	info, ok := c.cachedModel(model)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if _, err := c.ListModels(ctx); err != nil {
+			return 0, fmt.Errorf("unable to fetch pricing: %w", err)
+		}
+		if info, ok = c.cachedModel(model); !ok {
+			return 0, fmt.Errorf("no pricing known for model %q", model)
+		}
+	}
+
+	promptRate, err := strconv.ParseFloat(info.Pricing.Prompt, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad prompt price %q for model %q: %w", info.Pricing.Prompt, model, err)
+	}
+	completionRate, err := strconv.ParseFloat(info.Pricing.Completion, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad completion price %q for model %q: %w", info.Pricing.Completion, model, err)
+	}
+
+	return float64(usage.PromptTokens)*promptRate + float64(usage.CompletionTokens)*completionRate, nil
+}
+
+func (c *Client) cachedModel(model string) (ModelInfo, bool) {
+	c.modelsMu.Lock()
+	defer c.modelsMu.Unlock()
+
+	info, ok := c.models[model]
+	return info, ok
+}
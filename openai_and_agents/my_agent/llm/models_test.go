@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestClient_EstimateCost(t *testing.T) {
+	usage := Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+
+	t.Run("injected pricing", func(t *testing.T) {
+		client, err := NewClient("test-key")
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		client.SetModelPricing("cheap/model", ModelPricing{Prompt: "0.000001", Completion: "0.000002"})
+
+		got, err := client.EstimateCost("cheap/model", usage)
+		if err != nil {
+			t.Fatalf("EstimateCost failed: %v", err)
+		}
+
+		// 1000 * 0.000001 + 500 * 0.000002
+		if want := 0.002; math.Abs(got-want) > 1e-12 {
+			t.Errorf("got %v want %v", got, want)
+		}
+	})
+
+	t.Run("pricing fetched once from /models", func(t *testing.T) {
+		calls := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Write([]byte(`{"data":[{"id":"big/model","context_length":8192,"pricing":{"prompt":"0.00001","completion":"0.00003"}}]}`))
+		})
+
+		for range 2 {
+			got, err := client.EstimateCost("big/model", usage)
+			if err != nil {
+				t.Fatalf("EstimateCost failed: %v", err)
+			}
+			if want := 0.025; math.Abs(got-want) > 1e-12 {
+				t.Errorf("got %v want %v", got, want)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("got %d calls to /models want 1", calls)
+		}
+
+		if _, err := client.EstimateCost("unknown/model", usage); err == nil {
+			t.Error("expected an error for a model without pricing")
+		}
+	})
+}
+
+func TestClient_SupportsTools(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[
+			{"id":"smart/model","supported_parameters":["temperature","tools","tool_choice"]},
+			{"id":"tiny/model","supported_parameters":["temperature"]},
+			{"id":"quiet/model"}
+		]}`))
+	})
+
+	if _, known := client.SupportsTools("smart/model"); known {
+		t.Error("nothing should be known before ListModels")
+	}
+
+	if _, err := client.ListModels(context.Background()); err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+
+	cases := []struct {
+		model            string
+		supported, known bool
+	}{
+		{"smart/model", true, true},
+		{"tiny/model", false, true},
+		{"quiet/model", false, false},
+		{"missing/model", false, false},
+	}
+	for _, tc := range cases {
+		supported, known := client.SupportsTools(tc.model)
+		if supported != tc.supported || known != tc.known {
+			t.Errorf("%s: got (%v, %v) want (%v, %v)", tc.model, supported, known, tc.supported, tc.known)
+		}
+	}
+}
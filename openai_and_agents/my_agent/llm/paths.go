@@ -0,0 +1,42 @@
+package llm
+
+import "strings"
+
+const (
+	defaultCompletionsPath = "/chat/completions"
+	defaultModelsPath      = "/models"
+)
+
+// WithCompletionsPath changes the route chat requests (streamed or not) are sent to, relative to BaseURL
+// for openai compatible gateways that don't use /chat/completions
+func WithCompletionsPath(path string) ClientOption {
+	return func(c *Client) {
+		c.CompletionsPath = cleanPath(path)
+	}
+}
+
+// WithModelsPath changes the route Ping, ListModels and the pricing lookup use, relative to BaseURL
+func WithModelsPath(path string) ClientOption {
+	return func(c *Client) {
+		c.ModelsPath = cleanPath(path)
+	}
+}
+
+// cleanPath makes sure the path starts with exactly one slash so BaseURL + path is a valid url
+func cleanPath(path string) string {
+	return "/" + strings.TrimLeft(path, "/")
+}
+
+func (c *Client) completionsPath() string {
+	if c.CompletionsPath == "" {
+		return defaultCompletionsPath
+	}
+	return c.CompletionsPath
+}
+
+func (c *Client) modelsPath() string {
+	if c.ModelsPath == "" {
+		return defaultModelsPath
+	}
+	return c.ModelsPath
+}
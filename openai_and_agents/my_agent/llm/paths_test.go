@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestClient_CustomPaths(t *testing.T) {
+	var paths []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+		case "/v1/models":
+			w.Write([]byte(`{"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	WithCompletionsPath("/v1/chat/completions")(client)
+	WithModelsPath("v1/models")(client) // the leading slash is optional
+
+	if _, err := client.CreateChat(context.Background(), ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}); err != nil {
+		t.Fatalf("CreateChat failed: %v", err)
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	if len(paths) != 2 || paths[0] != "/v1/chat/completions" || paths[1] != "/v1/models" {
+		t.Errorf("got paths %v", paths)
+	}
+}
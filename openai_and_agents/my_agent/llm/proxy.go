@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WithProxy sends every api call through the given http, https or socks5 proxy
+// it is applied after the other options so it also works together with WithHTTPClient,
+// as long as that client uses an *http.Transport (or none, then the default one is copied)
+// a malformed url makes NewClient fail
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *Client) {
+		u, err := parseProxyURL(proxyURL)
+		if err != nil {
+			c.setErr(err)
+			return
+		}
+		c.proxy = u
+	}
+}
+
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %q: %w", proxyURL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy url %q: scheme must be http, https or socks5", proxyURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q: missing host", proxyURL)
+	}
+	return u, nil
+}
+
+// applyProxy points the transport at c.proxy
+// the http client and transport are copied first, the caller may be sharing them with other code
+func (c *Client) applyProxy() {
+	if c.proxy == nil {
+		return
+	}
+
+	var base *http.Client
+	if c.HTTPClient != nil {
+		copied := *c.HTTPClient
+		base = &copied
+	} else {
+		base = &http.Client{}
+	}
+
+	var transport *http.Transport
+	switch t := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		c.setErr(fmt.Errorf("WithProxy needs an *http.Transport, the http client uses a %T", t))
+		return
+	}
+
+	transport.Proxy = http.ProxyURL(c.proxy)
+	base.Transport = transport
+	c.HTTPClient = base
+}
+
+// setErr keeps the first option error for NewClient, later ones are usually a consequence of it
+func (c *Client) setErr(err error) {
+	if c.optErr == nil {
+		c.optErr = err
+	}
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_WithProxy(t *testing.T) {
+	t.Run("requests go through the proxy", func(t *testing.T) {
+		// a plain http proxy gets the absolute url of the real target in the request line
+		var proxied []string
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = append(proxied, r.URL.String())
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+		}))
+		defer proxy.Close()
+
+		client, err := NewClient("test-key", WithProxy(proxy.URL))
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		// this host doesn't exist, the call can only succeed if the proxy answers it
+		client.BaseURL = "http://api.example.invalid/v1"
+
+		resp, err := client.CreateChat(context.Background(), ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}})
+		if err != nil {
+			t.Fatalf("CreateChat failed: %v", err)
+		}
+		if resp.Choices[0].Message.Content != "ok" {
+			t.Errorf("got %q want %q", resp.Choices[0].Message.Content, "ok")
+		}
+
+		want := "http://api.example.invalid/v1/chat/completions"
+		if len(proxied) != 1 || proxied[0] != want {
+			t.Errorf("proxy saw %v want [%s]", proxied, want)
+		}
+	})
+
+	t.Run("works with a custom http client", func(t *testing.T) {
+		hc := &http.Client{}
+		client, err := NewClient("test-key", WithProxy("http://proxy.internal:3128"), WithHTTPClient(hc))
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		if client.HTTPClient == hc {
+			t.Errorf("the caller's http client was modified instead of copied")
+		}
+		if hc.Transport != nil {
+			t.Errorf("the caller's transport was changed")
+		}
+	})
+
+	t.Run("malformed url", func(t *testing.T) {
+		for _, proxyURL := range []string{"://nope", "proxy.internal:3128", "ftp://proxy.internal", "http://"} {
+			client, err := NewClient("test-key", WithProxy(proxyURL))
+			if err == nil || client != nil {
+				t.Errorf("%q: got %v, %v want a nil client and an error", proxyURL, client, err)
+			}
+		}
+	})
+}
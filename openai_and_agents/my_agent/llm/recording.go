@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// WithHTTPClient swaps the http client the api calls go through
+// for timeouts, proxies or a RecordingTransport in tests
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = hc
+	}
+}
+
+type RecordMode int
+
+const (
+	// ModeRecord sends requests for real and saves every request/response pair as a cassette
+	ModeRecord RecordMode = iota
+	// ModeReplay never touches the network, responses come from the saved cassettes
+	ModeReplay
+)
+
+// RecordingTransport records api interactions once and replays them later, for deterministic integration tests
+// a cassette is one json file in Dir named after a hash of method, path and body
+// so the same request always finds the same response no matter which host it was recorded against
+// headers are not part of the match and the Authorization header is never written to disk
+//
+//	hc := &http.Client{Transport: llm.NewRecordingTransport("testdata/cassettes", llm.ModeReplay)}
+//	client, err := llm.NewClient(key, llm.WithHTTPClient(hc))
+type RecordingTransport struct {
+	Dir  string
+	Mode RecordMode
+
+	// where recorded requests really go, http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+func NewRecordingTransport(dir string, mode RecordMode) *RecordingTransport {
+	return &RecordingTransport{Dir: dir, Mode: mode}
+}
+
+// cassette is what ends up on disk, bodies are kept as text since streams are not json
+type cassette struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string `json:"method"`
+	// path and query, /models?supported_parameters=tools and /models are different requests
+	Path string `json:"path"`
+	Body string `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+	}
+
+	recorded := recordedRequest{Method: req.Method, Path: req.URL.RequestURI(), Body: string(body)}
+	path := t.cassettePath(recorded)
+
+	if t.Mode == ModeReplay {
+		return t.replay(req, path)
+	}
+
+	// the upstream transport needs the body we just consumed
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return t.record(req, recorded, path)
+}
+
+func (t *RecordingTransport) record(req *http.Request, recorded recordedRequest, path string) (*http.Response, error) {
+	upstream := t.Transport
+	if upstream == nil {
+		upstream = http.DefaultTransport
+	}
+
+	resp, err := upstream.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cassette{
+		Request:  recorded,
+		Response: recordedResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: string(respBody)},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create cassette dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("unable to write cassette: %w", err)
+	}
+
+	// the original body is used up, hand the caller a fresh reader over the same bytes
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (t *RecordingTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no cassette for %s %s, record it first", req.Method, req.URL.RequestURI())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cassette: %w", err)
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("unable to decode cassette %s: %w", path, err)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(c.Response.StatusCode),
+		StatusCode:    c.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Response.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(c.Response.Body))),
+		ContentLength: int64(len(c.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (t *RecordingTransport) cassettePath(r recordedRequest) string {
+	sum := sha256.Sum256([]byte(r.Method + " " + r.Path + "\n" + r.Body))
+	return filepath.Join(t.Dir, hex.EncodeToString(sum[:])[:16]+".json")
+}
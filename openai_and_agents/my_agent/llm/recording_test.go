@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordingTransport(t *testing.T) {
+	dir := t.TempDir()
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"recorded hello"}}]}`))
+	}))
+
+	// record against the real (fake) server
+	recorder := &http.Client{Transport: NewRecordingTransport(dir, ModeRecord)}
+	client, err := NewClient("secret-key", WithHTTPClient(recorder))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+
+	resp, err := client.CreateChat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("recording CreateChat failed: %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "recorded hello" {
+		t.Fatalf("got %q want %q", got, "recorded hello")
+	}
+
+	server.Close()
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("got %d cassettes want 1", len(files))
+	}
+	data, _ := os.ReadFile(dir + "/" + files[0].Name())
+	if strings.Contains(string(data), "secret-key") {
+		t.Error("the api key ended up in the cassette")
+	}
+
+	// replay with the server gone, only the cassette can answer now
+	player := &http.Client{Transport: NewRecordingTransport(dir, ModeReplay)}
+	client, err = NewClient("other-key", WithHTTPClient(player))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.BaseURL = server.URL
+	client.MaxRetries = 0
+
+	resp, err = client.CreateChat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("replayed CreateChat failed: %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "recorded hello" {
+		t.Errorf("got %q want %q", got, "recorded hello")
+	}
+	if calls != 1 {
+		t.Errorf("got %d server calls want 1, replay should not hit the network", calls)
+	}
+
+	t.Run("unknown request", func(t *testing.T) {
+		other := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("something else")}}
+		if _, err := client.CreateChat(context.Background(), other); err == nil {
+			t.Error("expected an error for a request without a cassette")
+		}
+	})
+}
+
+func TestRecordingTransport_QueryIsPartOfTheKey(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	}))
+
+	get := func(client *http.Client, query string) string {
+		t.Helper()
+		resp, err := client.Get(server.URL + "/models?" + query)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	recorder := &http.Client{Transport: NewRecordingTransport(dir, ModeRecord)}
+	get(recorder, "page=1")
+	get(recorder, "page=2")
+	server.Close()
+
+	player := &http.Client{Transport: NewRecordingTransport(dir, ModeReplay)}
+	for _, query := range []string{"page=1", "page=2"} {
+		if got := get(player, query); got != query {
+			t.Errorf("got %q replayed for %q, the query must pick the cassette", got, query)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamIdle is the Err of the *StreamError a stream ends with when nothing arrived for StreamIdleTimeout
+var ErrStreamIdle = errors.New("llm: stream idle timeout")
+
+// WithStreamIdleTimeout ends a stream that goes quiet for longer than d, the connection is dropped
+// and the error is a *StreamError wrapping ErrStreamIdle (with whatever text came before)
+// unlike a ctx deadline it doesn't limit how long the whole reply takes, only the gap between two lines
+// sse keep alive comments count as activity, a provider still sending them is not stalled
+// time spent waiting for the caller to take a token doesn't count, a slow consumer is not an idle server
+func WithStreamIdleTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.StreamIdleTimeout = d
+	}
+}
+
+// idleWatch cancels the stream's context when it isn't touched within d
+// a zero d never fires, so the stream code doesn't need to care whether the option is on
+type idleWatch struct {
+	d     time.Duration
+	timer *time.Timer
+	fired atomic.Bool
+}
+
+func startIdleWatch(d time.Duration, cancel context.CancelFunc) *idleWatch {
+	w := &idleWatch{d: d}
+	if d > 0 {
+		w.timer = time.AfterFunc(d, func() {
+			w.fired.Store(true)
+			cancel()
+		})
+	}
+	return w
+}
+
+func (w *idleWatch) touch() {
+	if w.timer != nil {
+		w.timer.Reset(w.d)
+	}
+}
+
+// pause stops the clock while we wait on the caller, a slow consumer is not a stalled server
+// the next touch starts it again
+func (w *idleWatch) pause() {
+	w.stop()
+}
+
+func (w *idleWatch) stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// err swaps the context error we caused for ErrStreamIdle
+func (w *idleWatch) err(err error) error {
+	if w.fired.Load() {
+		return ErrStreamIdle
+	}
+	return err
+}
+
+// StreamError is what lands on the error channel when a stream breaks halfway
+// Partial holds all the text that made it through before the break, so callers can still show or keep it
+type StreamError struct {
+	Partial string
+	Err     error
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("stream interrupted after %d bytes: %v", len(e.Partial), e.Err)
+}
+
+func (e *StreamError) Unwrap() error { return e.Err }
+
+// CreateChatStream sends the request with stream on and hands back the text deltas as they arrive
+// both channels get closed when the stream is over, the error channel carries at most one error
+//
+// if the stream breaks after some text already came through (dropped connection, body cut short)
+// the deltas sent so far stay valid and the error is a *StreamError with the accumulated Partial text
+// a stream counts as complete once we see [DONE] or a finish_reason, ending before that is io.ErrUnexpectedEOF
+// Client.Close cancels the stream like a cancelled ctx would
+//
+// a caller that stops reading early has to cancel ctx, that is what stops the reader goroutine and closes the body
+// without it the goroutine waits on the token channel for as long as the stream is open
+func (c *Client) CreateChatStream(ctx context.Context, req ChatRequest) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	errs := make(chan error, 1)
+
+	// tracked so Close can cancel it and wait until both channels are closed
+	ctx, done, err := c.streams.add(ctx)
+	if err != nil {
+		close(tokens)
+		errs <- err
+		close(errs)
+		return tokens, errs
+	}
+
+	go func() {
+		defer done()
+		defer close(tokens)
+		defer close(errs)
+
+		if err := c.stream(ctx, req, tokens); err != nil {
+			errs <- err
+		}
+	}()
+
+	return tokens, errs
+}
+
+func (c *Client) stream(ctx context.Context, req ChatRequest, tokens chan<- string) error {
+	req.Stream = true
+	c.logRequest(req)
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("Unable to marshal Data here please check again %w", err)
+	}
+
+	// the request has to run on a context the idle watch can cancel,
+	// cancelling it is what unblocks a Scan stuck on a silent body
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	httpReq, err := c.newRequest(ctx, "POST", c.completionsPath(), jsonData)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return &NetworkError{Err: err}
+	}
+	// whatever way we leave, the body gets closed so the connection is freed
+	// only a stream that ended cleanly is drained first (to hand its connection back for reuse),
+	// on any other way out the body may still be live or stalled and a read could block forever
+	clean := false
+	defer func() {
+		if clean {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		}
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return errorFromResponse(resp, c.clock().Now())
+	}
+
+	// the idle clock starts once the headers are in, waiting for them is the http client's job
+	idle := startIdleWatch(c.StreamIdleTimeout, cancel)
+	defer idle.stop()
+
+	var partial strings.Builder
+	finished := false
+
+	// sse is line based: "data: {...}" lines, blank lines in between, ": comments" as keep alives
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		idle.touch()
+		line := scanner.Text()
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+
+		if data == "[DONE]" {
+			clean = true
+			return nil
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return &StreamError{Partial: partial.String(), Err: fmt.Errorf("error decoding chunk: %w", err)}
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				finished = true
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+
+			idle.pause()
+			select {
+			case tokens <- choice.Delta.Content:
+				partial.WriteString(choice.Delta.Content)
+				idle.touch()
+			case <-ctx.Done():
+				return &StreamError{Partial: partial.String(), Err: idle.err(ctx.Err())}
+			}
+		}
+	}
+
+	err = scanner.Err()
+	if err == nil && !finished {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+			err = ctxErr
+		}
+		err = idle.err(err)
+		return &StreamError{Partial: partial.String(), Err: err}
+	}
+	clean = true
+	return nil
+}
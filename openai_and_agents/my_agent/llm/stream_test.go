@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseHandler writes each chunk as a "data:" line, done decides whether the stream ends cleanly
+func sseHandler(done bool, chunks ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range chunks {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":%q}}]}\n\n", content)
+		}
+		if done {
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}
+	}
+}
+
+// drain reads the whole stream the way a caller would
+func drain(tokens <-chan string, errs <-chan error) (string, error) {
+	var got strings.Builder
+	for token := range tokens {
+		got.WriteString(token)
+	}
+	return got.String(), <-errs
+}
+
+func TestClient_CreateChatStream(t *testing.T) {
+	req := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	t.Run("complete stream", func(t *testing.T) {
+		client := newTestClient(t, sseHandler(true, "Hello", " world"))
+
+		got, err := drain(client.CreateChatStream(context.Background(), req))
+		if err != nil {
+			t.Fatalf("got error %v", err)
+		}
+		if got != "Hello world" {
+			t.Errorf("got %q want %q", got, "Hello world")
+		}
+	})
+
+	t.Run("truncated stream keeps the partial content", func(t *testing.T) {
+		// body ends without a finish_reason or [DONE], like a dropped connection
+		client := newTestClient(t, sseHandler(false, "Hello", " wor"))
+
+		got, err := drain(client.CreateChatStream(context.Background(), req))
+
+		if got != "Hello wor" {
+			t.Errorf("got tokens %q want %q", got, "Hello wor")
+		}
+
+		var streamErr *StreamError
+		if !errors.As(err, &streamErr) {
+			t.Fatalf("got %v, want a *StreamError", err)
+		}
+		if streamErr.Partial != "Hello wor" {
+			t.Errorf("got partial %q want %q", streamErr.Partial, "Hello wor")
+		}
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got %v, want it to wrap io.ErrUnexpectedEOF", err)
+		}
+	})
+
+	t.Run("stalled stream hits the idle timeout", func(t *testing.T) {
+		// one chunk, then the provider hangs without closing the body
+		release := make(chan struct{})
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n")
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		})
+		defer close(release)
+		client.StreamIdleTimeout = 50 * time.Millisecond
+
+		got, err := drain(client.CreateChatStream(context.Background(), req))
+
+		if got != "Hel" {
+			t.Errorf("got tokens %q want %q", got, "Hel")
+		}
+		if !errors.Is(err, ErrStreamIdle) {
+			t.Fatalf("got %v, want ErrStreamIdle", err)
+		}
+		var streamErr *StreamError
+		if !errors.As(err, &streamErr) || streamErr.Partial != "Hel" {
+			t.Errorf("got %v, want a *StreamError with the partial text", err)
+		}
+	})
+
+	t.Run("bad chunk on a stalled body returns right away", func(t *testing.T) {
+		release := make(chan struct{})
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "data: {not json\n\n")
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		})
+		defer close(release)
+		client.StreamIdleTimeout = 50 * time.Millisecond
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := drain(client.CreateChatStream(context.Background(), req))
+			result <- err
+		}()
+
+		select {
+		case err := <-result:
+			if err == nil || !strings.Contains(err.Error(), "error decoding chunk") {
+				t.Errorf("got %v want the decoding error", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("the stream is stuck draining a stalled body")
+		}
+	})
+
+	t.Run("slow but steady stream is not idle", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			for _, content := range []string{"a", "b", "c"} {
+				fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+				w.(http.Flusher).Flush()
+				time.Sleep(30 * time.Millisecond)
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		})
+		client.StreamIdleTimeout = 200 * time.Millisecond
+
+		got, err := drain(client.CreateChatStream(context.Background(), req))
+		if err != nil || got != "abc" {
+			t.Errorf("got %q, %v want %q and no error", got, err, "abc")
+		}
+	})
+
+	t.Run("slow consumer is not idle", func(t *testing.T) {
+		client := newTestClient(t, sseHandler(true, "a", "b", "c"))
+		client.StreamIdleTimeout = 50 * time.Millisecond
+
+		tokens, errs := client.CreateChatStream(context.Background(), req)
+		var got strings.Builder
+		for token := range tokens {
+			got.WriteString(token)
+			time.Sleep(150 * time.Millisecond)
+		}
+		if err := <-errs; err != nil || got.String() != "abc" {
+			t.Errorf("got %q, %v want %q and no error", got.String(), err, "abc")
+		}
+	})
+
+	t.Run("cancelling midway stops the reader and closes the body", func(t *testing.T) {
+		// the server would go on forever, only a closed connection stops it
+		hungUp := make(chan struct{})
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			defer close(hungUp)
+			for i := 0; ; i++ {
+				fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"%d \"}}]}\n\n", i)
+				w.(http.Flusher).Flush()
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(time.Millisecond):
+				}
+			}
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		tokens, errs := client.CreateChatStream(ctx, req)
+		<-tokens
+		<-tokens
+		// stop consuming: cancel and never read tokens again
+		cancel()
+
+		// the stream group's WaitGroup is what the reader goroutine signals on exit
+		exited := make(chan struct{})
+		go func() {
+			client.streams.wg.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the reader goroutine is still running after cancel")
+		}
+
+		select {
+		case <-hungUp:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the server never saw the connection close")
+		}
+
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v want context.Canceled", err)
+		}
+	})
+}
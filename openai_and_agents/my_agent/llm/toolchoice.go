@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ChatRequest.ToolChoice stays interface{} since the api takes either a string or an object
+// but these helpers are the way to fill it, they always produce a shape the api accepts
+//
+//	req.ToolChoice = llm.ToolChoiceFunction("get_weather")
+
+// ToolChoiceAuto lets the model decide whether to call a tool (the default when tools are sent)
+func ToolChoiceAuto() string { return "auto" }
+
+// ToolChoiceNone forbids tool calls for this request even if tools are sent
+func ToolChoiceNone() string { return "none" }
+
+// ToolChoiceRequired forces the model to call some tool, it picks which
+func ToolChoiceRequired() string { return "required" }
+
+// ToolChoiceFunction forces a call to one specific tool
+func ToolChoiceFunction(name string) NamedToolChoice {
+	return NamedToolChoice{
+		Type:     "function",
+		Function: ToolChoiceName{Name: name},
+	}
+}
+
+// NamedToolChoice is the object form {"type":"function","function":{"name":"..."}}
+type NamedToolChoice struct {
+	Type     string         `json:"type"`
+	Function ToolChoiceName `json:"function"`
+}
+
+type ToolChoiceName struct {
+	Name string `json:"name"`
+}
+
+// MarshalJSON refuses a choice without a tool name, catching it here beats a 400 from the provider
+func (c NamedToolChoice) MarshalJSON() ([]byte, error) {
+	if c.Function.Name == "" {
+		return nil, errors.New("tool choice needs a function name")
+	}
+	if c.Type == "" {
+		c.Type = "function"
+	}
+
+	// the alias drops the methods so this does not call itself forever
+	type plain NamedToolChoice
+	return json.Marshal(plain(c))
+}
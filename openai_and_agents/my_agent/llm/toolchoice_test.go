@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToolChoiceHelpers(t *testing.T) {
+	cases := []struct {
+		name   string
+		choice any
+		want   string
+	}{
+		{"auto", ToolChoiceAuto(), `"auto"`},
+		{"none", ToolChoiceNone(), `"none"`},
+		{"required", ToolChoiceRequired(), `"required"`},
+		{"function", ToolChoiceFunction("get_weather"), `{"type":"function","function":{"name":"get_weather"}}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := json.Marshal(ChatRequest{ToolChoice: tc.choice})
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			var raw map[string]json.RawMessage
+			json.Unmarshal(got, &raw)
+			if string(raw["tool_choice"]) != tc.want {
+				t.Errorf("got %s want %s", raw["tool_choice"], tc.want)
+			}
+		})
+	}
+
+	t.Run("function without a name", func(t *testing.T) {
+		if _, err := json.Marshal(ChatRequest{ToolChoice: ToolChoiceFunction("")}); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
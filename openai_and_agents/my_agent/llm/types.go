@@ -1,5 +1,11 @@
 package llm
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 // omitempty is essentially a way to tell the whole struct that you can just omit mentioning this whole thing when wanting to work with
 type ChatRequest struct {
 	// Required
@@ -18,11 +24,34 @@ type ChatRequest struct {
 	User             string          `json:"user,omitempty"`
 	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
 	Seed             int             `json:"seed,omitempty"`
+	Logprobs         bool            `json:"logprobs,omitempty"`     // ask for the log probability of every generated token
+	TopLogprobs      int             `json:"top_logprobs,omitempty"` // alternatives per token, needs Logprobs
+
+	// OpenRouter prompt transforms, ["middle-out"] squeezes a prompt that is over the context length
+	// so the provider does the trimming instead of us, left out when empty
+	Transforms []string `json:"transforms,omitempty"`
 
 	// Tool Calling Configuration
 	// interface{} is essentially way of saying that " Put anything inside of this {} and we will accept it "
 	Tools      []Tool      `json:"tools,omitempty"`
-	ToolChoice interface{} `json:"tool_choice,omitempty"` // Can be "auto", "none", or a specific tool object
+	ToolChoice interface{} `json:"tool_choice,omitempty"` // Can be "auto", "none", or a specific tool object, build it with the ToolChoice* helpers
+
+	// TemperatureSet sends Temperature even when it is 0 (greedy decoding), omitempty would drop it otherwise
+	TemperatureSet bool `json:"-"`
+}
+
+// MarshalJSON is the plain encoding plus the temperature that TemperatureSet asks for
+func (r ChatRequest) MarshalJSON() ([]byte, error) {
+	// plain has the fields but not this method, so json.Marshal below doesn't come back here
+	type plain ChatRequest
+	out := struct {
+		plain
+		Temperature *float64 `json:"temperature,omitempty"`
+	}{plain: plain(r)}
+	if r.Temperature != 0 || r.TemperatureSet {
+		out.Temperature = &r.Temperature
+	}
+	return json.Marshal(out)
 }
 
 // another struct for message passing with its corresponding json
@@ -52,6 +81,9 @@ type ToolCall struct {
 	ID       string       `json:"id"`
 	Type     string       `json:"type"` // Usually "function"
 	Function FunctionCall `json:"function"`
+
+	// only in stream deltas: which tool call this fragment belongs to, the id only comes with the first fragment
+	Index *int `json:"index,omitempty"`
 }
 
 type FunctionCall struct {
@@ -59,6 +91,31 @@ type FunctionCall struct {
 	Arguments string `json:"arguments"` // This is a JSON STRING, not an object!
 }
 
+// ArgsMap parses Arguments into a plain map, for looking at a tool call without a struct for it
+// empty arguments (some models send "" for no-arg tools) give an empty map, so does "null"
+func (fc FunctionCall) ArgsMap() (map[string]any, error) {
+	args := make(map[string]any)
+	if err := fc.Unmarshal(&args); err != nil {
+		return nil, err
+	}
+	// json null sets the map itself to nil, callers are promised a map they can write to
+	if args == nil {
+		args = make(map[string]any)
+	}
+	return args, nil
+}
+
+// Unmarshal decodes Arguments into v, same as json.Unmarshal but with the tool name in the error
+func (fc FunctionCall) Unmarshal(v any) error {
+	if strings.TrimSpace(fc.Arguments) == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(fc.Arguments), v); err != nil {
+		return fmt.Errorf("invalid arguments for %q: %w", fc.Name, err)
+	}
+	return nil
+}
+
 // Phase 2
 // This is where we need to establish what we get back from the API
 // this is where we do the rest of the stuff of return like response and choice ??
@@ -73,10 +130,30 @@ type ChatResponse struct {
 }
 
 type Choice struct {
-	Index        int         `json:"index"`
-	Message      Message     `json:"message"`
-	FinishReason string      `json:"finish_reason"`      // "stop", "length", "tool_calls", "content_filter"
-	Logprobs     interface{} `json:"logprobs,omitempty"` // Null unless top_logprobs set
+	Index        int       `json:"index"`
+	Message      Message   `json:"message"`
+	FinishReason string    `json:"finish_reason"`      // "stop", "length", "tool_calls", "content_filter"
+	Logprobs     *Logprobs `json:"logprobs,omitempty"` // Null unless the request asked for logprobs
+}
+
+// Logprobs is the token level confidence of a choice, one entry per generated token
+type Logprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
+
+// TokenLogprob is one generated token with its log probability
+// TopLogprobs lists the most likely alternatives at that position, as many as ChatRequest.TopLogprobs asked for
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
 }
 
 // typical telemetry about what all was consumed in the process
@@ -86,6 +163,33 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// Add sums two usages, handy for keeping a running total over many calls
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// StreamChunk is one "data: {...}" event of a streamed response (object "chat.completion.chunk")
+type StreamChunk struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object,omitempty"`
+	Created int64          `json:"created,omitempty"`
+	Model   string         `json:"model,omitempty"`
+	Choices []StreamChoice `json:"choices"`
+}
+
+// StreamChoice carries a Delta instead of a full Message, only the new bits since the last chunk are set
+// tool call arguments arrive in pieces too: each fragment has the tool call Index and a slice of the Arguments string
+// so they have to be glued together per Index before they are valid json
+type StreamChoice struct {
+	Index        int     `json:"index"`
+	Delta        Message `json:"delta"`
+	FinishReason string  `json:"finish_reason"` // empty until the last chunk
+}
+
 type ResponseFormat struct {
 	Type string `json:"type"` // text of json object
 }
@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStreamChunk_Decode(t *testing.T) {
+	// a tool call fragment: no id, no content, just a slice of the arguments for tool call 0
+	sample := `{
+		"id": "gen-123",
+		"object": "chat.completion.chunk",
+		"created": 1700000000,
+		"model": "openai/gpt-4o",
+		"choices": [{
+			"index": 0,
+			"delta": {
+				"role": "assistant",
+				"content": null,
+				"tool_calls": [{"index": 0, "function": {"arguments": "{\"city\": \"Par"}}]
+			},
+			"finish_reason": null
+		}]
+	}`
+
+	var chunk StreamChunk
+	if err := json.Unmarshal([]byte(sample), &chunk); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if chunk.ID != "gen-123" || chunk.Model != "openai/gpt-4o" || len(chunk.Choices) != 1 {
+		t.Fatalf("got %+v", chunk)
+	}
+
+	choice := chunk.Choices[0]
+	if choice.FinishReason != "" || choice.Delta.Content != "" {
+		t.Errorf("got finish %q content %q, want both empty", choice.FinishReason, choice.Delta.Content)
+	}
+
+	if len(choice.Delta.ToolCalls) != 1 {
+		t.Fatalf("got %d tool calls want 1", len(choice.Delta.ToolCalls))
+	}
+	call := choice.Delta.ToolCalls[0]
+	if call.Index == nil || *call.Index != 0 {
+		t.Errorf("got index %v want 0", call.Index)
+	}
+	if call.Function.Arguments != `{"city": "Par` {
+		t.Errorf("got partial arguments %q", call.Function.Arguments)
+	}
+}
+
+func TestToolCall_IndexOmittedOutsideStreams(t *testing.T) {
+	b, _ := json.Marshal(ToolCall{ID: "call_1", Type: "function", Function: FunctionCall{Name: "f", Arguments: "{}"}})
+
+	var raw map[string]any
+	json.Unmarshal(b, &raw)
+	if _, ok := raw["index"]; ok {
+		t.Errorf("got %s, want no index field on a regular tool call", b)
+	}
+}
+
+func TestChatRequest_Transforms(t *testing.T) {
+	b, _ := json.Marshal(ChatRequest{Model: "m", Transforms: []string{"middle-out"}})
+	if !strings.Contains(string(b), `"transforms":["middle-out"]`) {
+		t.Errorf("got %s, want the transforms array", b)
+	}
+
+	b, _ = json.Marshal(ChatRequest{Model: "m"})
+	if strings.Contains(string(b), "transforms") {
+		t.Errorf("got %s, want transforms left out when empty", b)
+	}
+}
+
+func TestChatRequest_Temperature(t *testing.T) {
+	tests := []struct {
+		name string
+		req  ChatRequest
+		want string
+	}{
+		{"unset zero is left out", ChatRequest{Model: "m"}, ""},
+		{"explicit zero is sent", ChatRequest{Model: "m", TemperatureSet: true}, `"temperature":0`},
+		{"non zero is sent", ChatRequest{Model: "m", Temperature: 0.5}, `"temperature":0.5`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if tt.want == "" && strings.Contains(string(b), "temperature") {
+				t.Errorf("got %s, want no temperature", b)
+			}
+			if tt.want != "" && !strings.Contains(string(b), tt.want) {
+				t.Errorf("got %s, want %s", b, tt.want)
+			}
+			if strings.Contains(string(b), "TemperatureSet") {
+				t.Errorf("got %s, TemperatureSet must not go on the wire", b)
+			}
+		})
+	}
+}
+
+func TestFunctionCall_Args(t *testing.T) {
+	call := FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris","days":3}`}
+
+	t.Run("map", func(t *testing.T) {
+		args, err := call.ArgsMap()
+		if err != nil {
+			t.Fatalf("ArgsMap failed: %v", err)
+		}
+		// json numbers come back as float64 in a map
+		if args["city"] != "Paris" || args["days"] != float64(3) {
+			t.Errorf("got %v", args)
+		}
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		var args struct {
+			City string `json:"city"`
+			Days int    `json:"days"`
+		}
+		if err := call.Unmarshal(&args); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if args.City != "Paris" || args.Days != 3 {
+			t.Errorf("got %+v", args)
+		}
+	})
+
+	t.Run("empty arguments", func(t *testing.T) {
+		args, err := FunctionCall{Name: "get_time"}.ArgsMap()
+		if err != nil || len(args) != 0 {
+			t.Errorf("got (%v, %v) want an empty map", args, err)
+		}
+	})
+
+	t.Run("null arguments", func(t *testing.T) {
+		args, err := FunctionCall{Name: "get_time", Arguments: "null"}.ArgsMap()
+		if err != nil || args == nil || len(args) != 0 {
+			t.Errorf("got (%#v, %v) want an empty, non nil map", args, err)
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		bad := FunctionCall{Name: "get_weather", Arguments: `{"city": "Par`}
+
+		if _, err := bad.ArgsMap(); err == nil {
+			t.Error("ArgsMap: expected an error but didn't get one")
+		}
+		var v struct{}
+		if err := bad.Unmarshal(&v); err == nil || !strings.Contains(err.Error(), "get_weather") {
+			t.Errorf("Unmarshal: got %v want an error naming the tool", err)
+		}
+	})
+}
+
+func TestChoice_DecodeLogprobs(t *testing.T) {
+	sample := `{
+		"index": 0,
+		"message": {"role": "assistant", "content": "yes"},
+		"finish_reason": "stop",
+		"logprobs": {"content": [{
+			"token": "yes",
+			"logprob": -0.05,
+			"bytes": [121, 101, 115],
+			"top_logprobs": [
+				{"token": "yes", "logprob": -0.05, "bytes": [121, 101, 115]},
+				{"token": "no", "logprob": -3.2, "bytes": [110, 111]}
+			]
+		}]}
+	}`
+
+	var choice Choice
+	if err := json.Unmarshal([]byte(sample), &choice); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if choice.Logprobs == nil || len(choice.Logprobs.Content) != 1 {
+		t.Fatalf("got logprobs %+v want one token", choice.Logprobs)
+	}
+	token := choice.Logprobs.Content[0]
+	if token.Token != "yes" || token.Logprob != -0.05 || len(token.Bytes) != 3 {
+		t.Errorf("got token %+v", token)
+	}
+	if len(token.TopLogprobs) != 2 || token.TopLogprobs[1].Token != "no" || token.TopLogprobs[1].Logprob != -3.2 {
+		t.Errorf("got top logprobs %+v", token.TopLogprobs)
+	}
+
+	t.Run("absent logprobs stay nil", func(t *testing.T) {
+		var plain Choice
+		json.Unmarshal([]byte(`{"index":0,"message":{"role":"assistant","content":"hi"},"logprobs":null}`), &plain)
+		if plain.Logprobs != nil {
+			t.Errorf("got %+v want nil", plain.Logprobs)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate catches the mistakes the provider would otherwise answer with an opaque 400
+// every problem found is reported, joined into one error
+func (r ChatRequest) Validate() error {
+	var errs []error
+
+	if r.Model == "" {
+		errs = append(errs, errors.New("model is empty"))
+	}
+	if len(r.Messages) == 0 {
+		errs = append(errs, errors.New("messages is empty"))
+	}
+	// 0 is in range whether it is unset or an explicit TemperatureSet 0
+	if r.Temperature < 0 || r.Temperature > 2 {
+		errs = append(errs, fmt.Errorf("temperature %v is outside [0, 2]", r.Temperature))
+	}
+	for i, msg := range r.Messages {
+		if msg.Name != "" {
+			if err := validateName(msg.Name); err != nil {
+				errs = append(errs, fmt.Errorf("message %d: %w", i, err))
+			}
+		}
+	}
+	if len(r.Tools) > 0 && r.ToolChoice == ToolChoiceNone() {
+		errs = append(errs, errors.New(`tools are set but tool_choice is "none", drop one of them`))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid chat request: %w", errors.Join(errs...))
+}
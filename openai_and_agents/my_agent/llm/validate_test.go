@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestChatRequest_Validate(t *testing.T) {
+	valid := ChatRequest{Model: "test-model", Messages: []Message{NewUserMessage("hi")}}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("got %v for a valid request", err)
+	}
+
+	tools := []Tool{{Type: "function", Function: FunctionDescription{Name: "get_weather"}}}
+
+	cases := []struct {
+		name   string
+		modify func(r *ChatRequest)
+		want   string
+	}{
+		{"empty model", func(r *ChatRequest) { r.Model = "" }, "model is empty"},
+		{"empty messages", func(r *ChatRequest) { r.Messages = nil }, "messages is empty"},
+		{"temperature too low", func(r *ChatRequest) { r.Temperature = -0.1 }, "temperature"},
+		{"temperature too high", func(r *ChatRequest) { r.Temperature = 2.5 }, "temperature"},
+		{"bad message name", func(r *ChatRequest) {
+			r.Messages = []Message{{Role: "user", Content: "hi", Name: "alice smith"}}
+		}, "invalid message name"},
+		{"tools with tool_choice none", func(r *ChatRequest) {
+			r.Tools = tools
+			r.ToolChoice = ToolChoiceNone()
+		}, "tool_choice"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid
+			tc.modify(&req)
+
+			err := req.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got %v want an error mentioning %q", err, tc.want)
+			}
+		})
+	}
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		err := ChatRequest{Temperature: 3}.Validate()
+		for _, want := range []string{"model", "messages", "temperature"} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("got %v want it to mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("tools with auto are fine", func(t *testing.T) {
+		req := valid
+		req.Tools = tools
+		req.ToolChoice = ToolChoiceAuto()
+		if err := req.Validate(); err != nil {
+			t.Errorf("got %v want nil", err)
+		}
+	})
+}
+
+func TestClient_CreateChatValidates(t *testing.T) {
+	called := false
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	if _, err := client.CreateChat(context.Background(), ChatRequest{}); err == nil {
+		t.Error("expected an error but didn't get one")
+	}
+	if called {
+		t.Error("an invalid request should never reach the api")
+	}
+}
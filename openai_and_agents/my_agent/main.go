@@ -18,7 +18,10 @@ func main() {
 	}
 
 	// Initialize Client this directly calls the llm NewClient to populate w api key
-	client := llm.NewClient(apiKey)
+	client, err := llm.NewClient(apiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize Agent (The Brain)
 	// We use the Functional Options pattern here unlike before where we were doing  it with structs/json
@@ -48,7 +51,7 @@ func main() {
 	// --- LEGACY: Manual Client Usage (Phase 1) ---
 	// This shows how we used to manually construct requests before we built the Agent abstraction.
 
-	newAgent := llm.NewClient(apiKey)
+	newAgent, err := llm.NewClient(apiKey)
 
 	req := llm.ChatRequest{
 		Model: "openai/gpt-5.2",
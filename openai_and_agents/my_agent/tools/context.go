@@ -0,0 +1,23 @@
+package tools
+
+import "context"
+
+// deps ride along inside the context so tool signatures stay clean
+// an unexported key type means nobody outside this package can clash with or overwrite it
+type depsKey struct{}
+
+// WithDeps stashes per call dependencies (db handle, current user ...) in the context
+// the agent does this for you when built with agent.WithDeps
+func WithDeps(ctx context.Context, deps any) context.Context {
+	return context.WithValue(ctx, depsKey{}, deps)
+}
+
+// FromContext gets the dependencies back inside a context accepting tool, nil if none were set
+//
+//	func lookupOrders(ctx context.Context, args OrderArgs) (string, error) {
+//		deps := tools.FromContext(ctx).(*AppDeps)
+//		...
+//	}
+func FromContext(ctx context.Context) any {
+	return ctx.Value(depsKey{})
+}
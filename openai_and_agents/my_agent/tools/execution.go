@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"my_agent/llm"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+// PanicError is what Call returns for a tool that panicked, Stack is where it happened
+// the message the model sees (Error) leaves the stack out, log it yourself if you need it
+type PanicError struct {
+	Tool  string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tool %q panicked: %v", e.Tool, e.Value)
+}
+
+// Call runs the tool the model asked for
+// args is the raw json string straight from ToolCall.Function.Arguments
+// it gets decoded into a fresh value of the tool's ArgsType and passed to the function via reflection
+func (r *Registry) Call(ctx context.Context, name string, args string) (string, error) {
+	tool, ok := r.lookup(name)
+	if !ok {
+		return "", fmt.Errorf("tool %q is not registered", name)
+	}
+
+	fnType := tool.Func.Type()
+
+	var in []reflect.Value
+	if takesContext(fnType) {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+
+	if tool.ArgsType != nil {
+		// reflect.New gives a pointer to a zero value, json needs the pointer to fill it in
+		argPtr := reflect.New(tool.ArgsType)
+		if strings.TrimSpace(args) != "" {
+			// before decoding, for a string in an int field the schema error says more than the json one
+			if err := r.checkSchema(tool, args); err != nil {
+				return "", err
+			}
+			if err := r.decodeArgs(name, args, argPtr.Interface()); err != nil {
+				return "", err
+			}
+		}
+
+		if tool.Params != nil {
+			// plain arguments, spread the generated struct back out field by field
+			for i := range tool.Params {
+				in = append(in, argPtr.Elem().Field(i))
+			}
+		} else {
+			in = append(in, argPtr.Elem())
+		}
+	}
+
+	policy := r.retry
+	if tool.Retry != nil {
+		policy = *tool.Retry
+	}
+
+	// retry only transient failures, everything else goes straight back to the model as a tool error
+	var err error
+	for attempt := 0; ; attempt++ {
+		var output string
+		output, err = invoke(tool, in)
+		if err == nil {
+			return output, nil
+		}
+		if panicErr, ok := err.(*PanicError); ok {
+			r.logf("tools: %v\n%s", panicErr, panicErr.Stack)
+			break
+		}
+		if attempt+1 >= policy.MaxAttempts || !isRetryable(err) {
+			break
+		}
+		// a run wide budget (see llm.WithRetryBudget) can stop us before the policy does
+		if !llm.RetryBudgetFrom(ctx).Take() {
+			err = fmt.Errorf("%w after %d attempts: %w", llm.ErrRetryBudgetExhausted, attempt+1, err)
+			break
+		}
+		if sleepErr := llm.SleepContext(ctx, r.clock, policy.Backoff.NextDelay(attempt)); sleepErr != nil {
+			break
+		}
+	}
+
+	return "", err
+}
+
+// decodeArgs tries the arguments as they are and only if that fails tries again after cleaning them up
+// (and after repairing them, with WithJSONRepair)
+// the strict error is the one reported, it describes what the model actually sent
+func (r *Registry) decodeArgs(name string, args string, v any) error {
+	err := r.unmarshalArgs(args, v)
+	if err == nil {
+		return nil
+	}
+
+	cleaned := cleanArguments(args)
+	if cleaned != args && r.unmarshalArgs(cleaned, v) == nil {
+		r.logf("tools: lenient parsing was needed for the arguments of %q", name)
+		return nil
+	}
+
+	if r.repair {
+		repaired := repairJSON(cleaned)
+		if repaired != cleaned && r.unmarshalArgs(repaired, v) == nil {
+			r.logf("tools: repaired the arguments of %q", name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid arguments for tool %q: %w", name, err)
+}
+
+// invoke is the actual reflected call, one attempt
+// a panicking tool (nil map write, index out of range ...) becomes a *PanicError instead of taking the process down
+func invoke(tool Tool, in []reflect.Value) (output string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &PanicError{Tool: tool.Name, Value: recovered, Stack: debug.Stack()}
+		}
+	}()
+
+	out := tool.Func.Call(in)
+
+	// second return is the error (checked at Register time), nil interface means all good
+	if len(out) == 2 && !out[1].IsNil() {
+		return "", out[1].Interface().(error)
+	}
+
+	return out[0].String(), nil
+}
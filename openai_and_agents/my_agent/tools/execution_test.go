@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+func GetCurrentTime() string {
+	return "2025-01-01T12:00:00Z"
+}
+
+func TestRegistry_CallZeroArgs(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.Register("get_current_time", "Get the current time", GetCurrentTime); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+
+	// the schema should be an empty object so the model knows to send {}
+	schemaJSON, _ := json.Marshal(registry.tools["get_current_time"].Schema)
+	if string(schemaJSON) != `{"properties":{},"type":"object"}` {
+		t.Errorf("got schema %s, want an empty object schema", schemaJSON)
+	}
+
+	got, err := registry.Call(context.Background(), "get_current_time", "{}")
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if got != "2025-01-01T12:00:00Z" {
+		t.Errorf("got %q want %q", got, "2025-01-01T12:00:00Z")
+	}
+}
+
+func TestRegistry_CallVariants(t *testing.T) {
+	registry := NewRegistry()
+
+	errBroken := errors.New("clock is broken")
+
+	registry.Register("ctx_time", "", func(ctx context.Context) (string, error) {
+		return "noon", nil
+	})
+	registry.Register("broken_time", "", func() (string, error) {
+		return "", errBroken
+	})
+
+	t.Run("context and error variants", func(t *testing.T) {
+		got, err := registry.Call(context.Background(), "ctx_time", "{}")
+		if err != nil || got != "noon" {
+			t.Errorf("got (%q, %v) want (%q, nil)", got, err, "noon")
+		}
+	})
+
+	t.Run("tool errors are returned", func(t *testing.T) {
+		_, err := registry.Call(context.Background(), "broken_time", "{}")
+		if !errors.Is(err, errBroken) {
+			t.Errorf("got %v want %v", err, errBroken)
+		}
+	})
+
+	t.Run("struct args are decoded", func(t *testing.T) {
+		registry.Register("get_weather", "", GetWeather)
+
+		got, err := registry.Call(context.Background(), "get_weather", `{"city":"Paris","days":2}`)
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if want := "Weather in Paris for 2 days is sunny"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+
+	t.Run("unknown tool", func(t *testing.T) {
+		if _, err := registry.Call(context.Background(), "nope", "{}"); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func Forecast(city string, days int) string {
+	return fmt.Sprintf("Weather in %s for %d days is sunny", city, days)
+}
+
+func TestRegistry_CallMultipleArgs(t *testing.T) {
+	t.Run("positional names", func(t *testing.T) {
+		registry := NewRegistry()
+		if err := registry.Register("forecast", "Get a forecast", Forecast); err != nil {
+			t.Fatalf("Failed to register tool: %v", err)
+		}
+
+		props := registry.tools["forecast"].Schema["properties"].(map[string]any)
+		if _, ok := props["arg0"]; !ok {
+			t.Errorf("Schema missing 'arg0' property, got %v", props)
+		}
+
+		got, err := registry.Call(context.Background(), "forecast", `{"arg0":"Paris","arg1":3}`)
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if want := "Weather in Paris for 3 days is sunny"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+
+	t.Run("named params", func(t *testing.T) {
+		registry := NewRegistry()
+		if err := registry.RegisterWithParams("forecast", "Get a forecast", Forecast, "city", "days"); err != nil {
+			t.Fatalf("Failed to register tool: %v", err)
+		}
+
+		got, err := registry.Call(context.Background(), "forecast", `{"city":"Oslo","days":1}`)
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if want := "Weather in Oslo for 1 days is sunny"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+
+	t.Run("wrong number of names", func(t *testing.T) {
+		registry := NewRegistry()
+		if err := registry.RegisterWithParams("forecast", "", Forecast, "city"); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func TestRegistry_CallRecoversPanics(t *testing.T) {
+	var logs bytes.Buffer
+	registry := NewRegistry(WithLogger(log.New(&logs, "", 0)))
+
+	registry.Register("buggy", "", func(args WeatherArgs) (string, error) {
+		var cache map[string]int
+		cache[args.City]++ // nil map write
+		return "unreachable", nil
+	})
+
+	_, err := registry.Call(context.Background(), "buggy", `{"city":"Paris"}`)
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("got %v want a *PanicError", err)
+	}
+	if !strings.Contains(err.Error(), "assignment to entry in nil map") {
+		t.Errorf("got %q want the panic value in the message", err.Error())
+	}
+	if !strings.Contains(string(panicErr.Stack), "TestRegistry_CallRecoversPanics") {
+		t.Error("the stack does not point at the panicking tool")
+	}
+	if !strings.Contains(logs.String(), "panicked") {
+		t.Errorf("got logs %q want the panic logged", logs.String())
+	}
+}
@@ -0,0 +1,104 @@
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// SchemaRegistry gives struct types a name so schemas can point at one shared definition
+// instead of inlining the same object again in every tool that uses it
+// a field of a defined type becomes {"$ref": "#/$defs/Name"} and the object itself lives once in Defs
+//
+//	defs := jsonschema.NewSchemaRegistry()
+//	defs.Define("Address", reflect.TypeOf(Address{}))
+//	params := defs.Generate(reflect.TypeOf(ShipArgs{}))
+//	params["$defs"] = defs.Defs()
+type SchemaRegistry struct {
+	// Options apply to the definitions and to everything Generate builds
+	Options GenerateSchemaOptions
+
+	mu    sync.Mutex
+	names map[reflect.Type]string
+	types map[string]reflect.Type
+	defs  map[string]map[string]any
+}
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		names: make(map[reflect.Type]string),
+		types: make(map[string]reflect.Type),
+		defs:  make(map[string]map[string]any),
+	}
+}
+
+// Define names a struct type, an empty name means the go type name
+// defining the same type twice under the same name is fine, reusing a name for another type is not
+func (s *SchemaRegistry) Define(name string, t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("only struct types can be defined, got %v", t)
+	}
+	if name == "" {
+		name = t.Name()
+	}
+	if name == "" {
+		return fmt.Errorf("anonymous struct types need an explicit name")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.types[name]; ok && existing != t {
+		return fmt.Errorf("the name %q is already used by %v", name, existing)
+	}
+	s.names[t] = name
+	s.types[name] = t
+	return nil
+}
+
+// Generate is GenerateSchema with defined types referenced instead of inlined
+// t itself is always generated in full, even when it is defined
+func (s *SchemaRegistry) Generate(t reflect.Type) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return generateSchema(t, s.Options, s)
+}
+
+// Defs is the shared $defs block for every definition Generate referenced so far
+// the maps are copies, changing them doesn't touch the registry
+func (s *SchemaRegistry) Defs() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]any, len(s.defs))
+	for name, def := range s.defs {
+		out[name] = copySchema(def)
+	}
+	return out
+}
+
+// ref is the $ref for a defined type, generating its definition the first time it is needed
+// the caller holds s.mu, a nil registry defines nothing
+func (s *SchemaRegistry) ref(t reflect.Type) (map[string]any, bool) {
+	if s == nil {
+		return nil, false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name, ok := s.names[t]
+	if !ok {
+		return nil, false
+	}
+
+	if _, done := s.defs[name]; !done {
+		// a placeholder first so a type that contains itself refers back instead of recursing forever
+		s.defs[name] = map[string]any{}
+		s.defs[name] = generateSchema(t, s.Options, s)
+	}
+	return map[string]any{"$ref": "#/$defs/" + name}, true
+}
@@ -0,0 +1,66 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+type shipArgs struct {
+	To      address `json:"to" description:"Where the parcel goes"`
+	Express bool    `json:"express,omitempty"`
+}
+
+type returnArgs struct {
+	From   address `json:"from"`
+	Reason string  `json:"reason"`
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	defs := NewSchemaRegistry()
+	if err := defs.Define("", reflect.TypeOf(address{})); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+
+	ship := defs.Generate(reflect.TypeOf(shipArgs{}))
+	ret := defs.Generate(reflect.TypeOf(returnArgs{}))
+
+	to := ship["properties"].(map[string]any)["to"].(map[string]any)
+	from := ret["properties"].(map[string]any)["from"].(map[string]any)
+	for _, field := range []map[string]any{to, from} {
+		if field["$ref"] != "#/$defs/address" {
+			t.Errorf("got %v want a $ref to the shared definition", field)
+		}
+		if _, inlined := field["properties"]; inlined {
+			t.Errorf("got %v, the object was inlined next to the $ref", field)
+		}
+	}
+	if to["description"] != "Where the parcel goes" {
+		t.Errorf("got %v want the field description kept next to the $ref", to)
+	}
+
+	all := defs.Defs()
+	if len(all) != 1 {
+		t.Fatalf("got %d definitions want exactly one: %v", len(all), all)
+	}
+	props := all["address"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := props["city"]; !ok {
+		t.Errorf("got definition %v want the address properties", all["address"])
+	}
+}
+
+func TestSchemaRegistry_Define(t *testing.T) {
+	defs := NewSchemaRegistry()
+
+	if err := defs.Define("Place", reflect.TypeOf(address{})); err != nil {
+		t.Fatalf("Define failed: %v", err)
+	}
+	if err := defs.Define("Place", reflect.TypeOf(shipArgs{})); err == nil {
+		t.Error("expected an error for a name already used by another type")
+	}
+	if err := defs.Define("", reflect.TypeOf(struct{ A int }{})); err == nil {
+		t.Error("expected an error for an anonymous struct without a name")
+	}
+	if err := defs.Define("Count", reflect.TypeOf(0)); err == nil {
+		t.Error("expected an error for a non struct type")
+	}
+}
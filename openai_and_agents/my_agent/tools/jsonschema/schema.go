@@ -2,12 +2,76 @@ package jsonschema
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+// GenerateSchemaOptions changes which fields end up in "required"
+// by default a field is required unless its json tag says omitempty
+type GenerateSchemaOptions struct {
+	// every field is required, omitempty or not
+	AllRequired bool
+	// nothing is required so the model can send partial arguments, wins over AllRequired
+	NoneRequired bool
+}
+
+// cache of finished schemas, shared by every registry in the process
+// a server registering the same args type over and over only pays for the reflection once
+var schemaCache sync.Map // cacheKey -> map[string]any
+
+// the same type can come out differently depending on the options so both make up the key
+type cacheKey struct {
+	t    reflect.Type
+	opts GenerateSchemaOptions
+}
+
 // GenerateSchema takes a struct type and returns a map[string]any
 // representing the JSON Schema required for OpenAI tool definitions.
+// results are cached per type, every caller gets its own copy so mutating it is safe
 func GenerateSchema(t reflect.Type) map[string]any {
+	return GenerateSchemaWith(t, GenerateSchemaOptions{})
+}
+
+// GenerateSchemaWith is GenerateSchema with control over the required list, nested objects follow the same options
+func GenerateSchemaWith(t reflect.Type, opts GenerateSchemaOptions) map[string]any {
+	key := cacheKey{t: t, opts: opts}
+	if cached, ok := schemaCache.Load(key); ok {
+		return copySchema(cached.(map[string]any))
+	}
+
+	schema := generateSchema(t, opts, nil)
+	if schema != nil {
+		schemaCache.Store(key, schema)
+	}
+	return copySchema(schema)
+}
+
+// copySchema deep copies the maps and slices a schema is made of, the cached original is never handed out
+func copySchema(schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+
+	out := make(map[string]any, len(schema))
+	for key, value := range schema {
+		switch v := value.(type) {
+		case map[string]any:
+			out[key] = copySchema(v)
+		case []string:
+			out[key] = append([]string(nil), v...)
+		case []any:
+			out[key] = append([]any(nil), v...)
+		default:
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// generateSchema does the actual reflection walk, it always builds fresh maps
+// with defs set, fields of a type defs knows become a $ref instead of being inlined
+func generateSchema(t reflect.Type, opts GenerateSchemaOptions, defs *SchemaRegistry) map[string]any {
 	// Handle pointers (dereference them)
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
@@ -23,6 +87,9 @@ func GenerateSchema(t reflect.Type) map[string]any {
 		return map[string]any{"type": "number"}
 	case reflect.Bool:
 		return map[string]any{"type": "boolean"}
+	case reflect.Interface:
+		// any / interface{} can hold anything, an empty schema is json schema for "any type"
+		return map[string]any{}
 	}
 
 	// Complex case: Structs
@@ -42,26 +109,56 @@ func GenerateSchema(t reflect.Type) map[string]any {
 
 			// Handle "omitempty"
 			name := jsonTag
+			isRequired := true
 			if strings.Contains(jsonTag, ",") {
 				parts := strings.Split(jsonTag, ",")
 				name = parts[0]
 				// If not omitempty, it's required
-				if !strings.Contains(jsonTag, "omitempty") {
-					required = append(required, name)
-				}
-			} else {
-				// No commas means required by default in our logic
+				isRequired = !strings.Contains(jsonTag, "omitempty")
+			}
+			// No commas means required by default in our logic, unless the options say otherwise
+			switch {
+			case opts.NoneRequired:
+				isRequired = false
+			case opts.AllRequired:
+				isRequired = true
+			}
+			if isRequired {
 				required = append(required, name)
 			}
 
 			// Recursively generate schema for the field's type
-			fieldSchema := GenerateSchema(field.Type)
+			fieldSchema, isRef := defs.ref(field.Type)
+			if !isRef {
+				fieldSchema = generateSchema(field.Type, opts, defs)
+			}
+
+			// nested objects get a title from their type name so the model can tell them apart
+			// anonymous structs have no name so they go without, a $ref already names its definition
+			if title := structName(field.Type); title != "" && !isRef {
+				fieldSchema["title"] = title
+			}
 
 			// Add description if present (e.g. `description:"City name"`)
+			// this works for nested objects too, the description sits right next to their properties
 			if desc := field.Tag.Get("description"); desc != "" {
 				fieldSchema["description"] = desc
 			}
 
+			// format:"email" (or uri, uuid, date-time ...) hints at what the string should look like
+			// json schema allows custom formats so anything is passed on, it only makes sense on strings though
+			if format := field.Tag.Get("format"); format != "" && fieldSchema["type"] == "string" {
+				fieldSchema["format"] = format
+			}
+
+			// default:"3" tells the model what it gets when it leaves the field out
+			// the value is parsed into the field's type, one that doesn't parse is skipped
+			if raw, ok := field.Tag.Lookup("default"); ok {
+				if value, ok := parseDefault(field.Type, raw); ok {
+					fieldSchema["default"] = value
+				}
+			}
+
 			properties[name] = fieldSchema
 		}
 
@@ -74,3 +171,37 @@ func GenerateSchema(t reflect.Type) map[string]any {
 
 	return nil
 }
+
+// parseDefault turns a default tag into a value of the right json type
+// only strings, numbers and bools have a sensible text form, other kinds never get a default
+func parseDefault(t reflect.Type, raw string) (any, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return raw, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, t.Bits())
+		return n, err == nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, t.Bits())
+		return f, err == nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		return b, err == nil
+	}
+	return nil, false
+}
+
+// structName is the type name of a (pointer to a) named struct, empty for everything else
+func structName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+	return t.Name()
+}
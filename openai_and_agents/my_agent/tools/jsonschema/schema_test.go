@@ -0,0 +1,193 @@
+package jsonschema
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type address struct {
+	Street string `json:"street"`
+	City   string `json:"city" description:"City name"`
+}
+
+type bookingArgs struct {
+	Name     string  `json:"name" description:"Who is booking"`
+	Guests   int     `json:"guests"`
+	Budget   float64 `json:"budget,omitempty"`
+	Smoking  bool    `json:"smoking,omitempty"`
+	Address  address `json:"address" description:"Where the guests live"`
+	Internal string
+}
+
+func TestGenerateSchema_CachedCopiesAreIndependent(t *testing.T) {
+	typ := reflect.TypeOf(bookingArgs{})
+
+	first := GenerateSchema(typ)
+	// scribble all over the first copy
+	first["properties"].(map[string]any)["name"].(map[string]any)["description"] = "changed"
+	first["required"] = []string{}
+
+	second := GenerateSchema(typ)
+
+	desc := second["properties"].(map[string]any)["name"].(map[string]any)["description"]
+	if desc != "Who is booking" {
+		t.Errorf("got description %q, the cached schema was mutated through a copy", desc)
+	}
+	if required := second["required"].([]string); len(required) != 3 {
+		t.Errorf("got required %v want name, guests and address", required)
+	}
+}
+
+func TestGenerateSchema_NestedStruct(t *testing.T) {
+	schema := GenerateSchema(reflect.TypeOf(bookingArgs{}))
+
+	nested := schema["properties"].(map[string]any)["address"].(map[string]any)
+
+	if nested["type"] != "object" {
+		t.Errorf("got type %v want object", nested["type"])
+	}
+	if nested["title"] != "address" {
+		t.Errorf("got title %v want %q", nested["title"], "address")
+	}
+	if nested["description"] != "Where the guests live" {
+		t.Errorf("got description %v want the field's description tag", nested["description"])
+	}
+
+	// the nested properties keep their own descriptions
+	city := nested["properties"].(map[string]any)["city"].(map[string]any)
+	if city["description"] != "City name" {
+		t.Errorf("got city description %v want %q", city["description"], "City name")
+	}
+
+	// plain fields don't get a title
+	if _, ok := schema["properties"].(map[string]any)["name"].(map[string]any)["title"]; ok {
+		t.Error("string field should not have a title")
+	}
+}
+
+func TestGenerateSchemaWith(t *testing.T) {
+	typ := reflect.TypeOf(bookingArgs{})
+
+	t.Run("all required", func(t *testing.T) {
+		schema := GenerateSchemaWith(typ, GenerateSchemaOptions{AllRequired: true})
+
+		// omitempty fields are required too
+		want := []string{"name", "guests", "budget", "smoking", "address"}
+		if got := schema["required"].([]string); !slices.Equal(got, want) {
+			t.Errorf("got required %v want %v", got, want)
+		}
+
+		nested := schema["properties"].(map[string]any)["address"].(map[string]any)
+		if got := nested["required"].([]string); !slices.Equal(got, []string{"street", "city"}) {
+			t.Errorf("got nested required %v want street and city", got)
+		}
+	})
+
+	t.Run("none required", func(t *testing.T) {
+		schema := GenerateSchemaWith(typ, GenerateSchemaOptions{NoneRequired: true})
+
+		if got := schema["required"].([]string); len(got) != 0 {
+			t.Errorf("got required %v want nothing", got)
+		}
+
+		nested := schema["properties"].(map[string]any)["address"].(map[string]any)
+		if got := nested["required"].([]string); len(got) != 0 {
+			t.Errorf("got nested required %v want nothing", got)
+		}
+	})
+
+	t.Run("default is unchanged", func(t *testing.T) {
+		// the lenient schema above must not leak into the cached default one
+		want := []string{"name", "guests", "address"}
+		if got := GenerateSchema(typ)["required"].([]string); !slices.Equal(got, want) {
+			t.Errorf("got required %v want %v", got, want)
+		}
+	})
+}
+
+func TestGenerateSchema_AnyField(t *testing.T) {
+	type eventArgs struct {
+		Name     string `json:"name"`
+		Metadata any    `json:"metadata,omitempty" description:"Passed through untouched"`
+	}
+
+	schema := GenerateSchema(reflect.TypeOf(eventArgs{}))
+	props := schema["properties"].(map[string]any)
+
+	metadata, ok := props["metadata"].(map[string]any)
+	if !ok {
+		t.Fatalf("got metadata schema %#v want a map", props["metadata"])
+	}
+	// permissive means no type at all, only the description tag is carried over
+	if _, hasType := metadata["type"]; hasType {
+		t.Errorf("got %v want no type for an any field", metadata)
+	}
+	if metadata["description"] != "Passed through untouched" {
+		t.Errorf("got description %v want the tag", metadata["description"])
+	}
+
+	if got := GenerateSchema(reflect.TypeOf((*any)(nil)).Elem()); got == nil || len(got) != 0 {
+		t.Errorf("got %v want the empty schema {}", got)
+	}
+}
+
+func TestGenerateSchema_Format(t *testing.T) {
+	type contactArgs struct {
+		Email   string `json:"email" format:"email"`
+		Website string `json:"website" format:"uri"`
+		Handle  string `json:"handle" format:"x-handle"`
+		Age     int    `json:"age" format:"email"`
+		Name    string `json:"name"`
+	}
+
+	props := GenerateSchema(reflect.TypeOf(contactArgs{}))["properties"].(map[string]any)
+
+	for field, want := range map[string]any{"email": "email", "website": "uri", "handle": "x-handle", "age": nil, "name": nil} {
+		if got := props[field].(map[string]any)["format"]; got != want {
+			t.Errorf("%s: got format %v want %v", field, got, want)
+		}
+	}
+}
+
+func TestGenerateSchema_Default(t *testing.T) {
+	type searchArgs struct {
+		Query  string  `json:"query"`
+		Sort   string  `json:"sort,omitempty" default:"relevance"`
+		Limit  int     `json:"limit,omitempty" default:"10"`
+		Score  float64 `json:"score,omitempty" default:"0.5"`
+		Safe   bool    `json:"safe,omitempty" default:"true"`
+		Broken int     `json:"broken,omitempty" default:"ten"`
+	}
+
+	props := GenerateSchema(reflect.TypeOf(searchArgs{}))["properties"].(map[string]any)
+
+	for field, want := range map[string]any{"sort": "relevance", "limit": int64(10), "score": 0.5, "safe": true} {
+		if got := props[field].(map[string]any)["default"]; got != want {
+			t.Errorf("%s: got default %#v want %#v", field, got, want)
+		}
+	}
+	for _, field := range []string{"query", "broken"} {
+		if got, ok := props[field].(map[string]any)["default"]; ok {
+			t.Errorf("%s: got default %#v want none", field, got)
+		}
+	}
+}
+
+// go test -bench Schema ./tools/jsonschema shows the cached path against a fresh walk
+func BenchmarkGenerateSchema_Uncached(b *testing.B) {
+	typ := reflect.TypeOf(bookingArgs{})
+	for range b.N {
+		generateSchema(typ, GenerateSchemaOptions{}, nil)
+	}
+}
+
+func BenchmarkGenerateSchema_Cached(b *testing.B) {
+	typ := reflect.TypeOf(bookingArgs{})
+	GenerateSchema(typ)
+
+	b.ResetTimer()
+	for range b.N {
+		GenerateSchema(typ)
+	}
+}
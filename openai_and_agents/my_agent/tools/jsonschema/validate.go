@@ -0,0 +1,200 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Validate checks a json document against a schema like the ones GenerateSchema builds
+// it looks at what tool arguments go wrong on: type, enum, required, properties, items and local $refs
+// everything else in the schema (format, default, descriptions ...) is ignored
+// null is accepted for a field that isn't required, the same as leaving it out
+// every violation is reported with its path, joined into one error, nil means the document fits
+func Validate(schema map[string]any, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// numbers stay as text so 2 and 2.5 can be told apart and big ints survive
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("not valid json: %w", err)
+	}
+
+	v := validator{root: schema}
+	v.check(schema, value, "")
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	root map[string]any
+	errs []error
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "arguments"
+	}
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) check(schema map[string]any, value any, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, ok := v.resolve(ref)
+		if !ok {
+			v.fail(path, "unknown $ref %q", ref)
+			return
+		}
+		schema = resolved
+	}
+
+	if want, ok := schema["type"].(string); ok {
+		if got := typeOf(value); !typeMatches(want, got, value) {
+			v.fail(path, "got %s want %s", got, want)
+			// the checks below would only repeat the same mistake
+			return
+		}
+	}
+
+	if enum := asSlice(schema["enum"]); enum != nil {
+		if !slices.ContainsFunc(enum, func(allowed any) bool { return sameValue(allowed, value) }) {
+			v.fail(path, "%s is not one of %v", jsonText(value), enum)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		required := make(map[string]bool)
+		for _, name := range asSlice(schema["required"]) {
+			name, _ := name.(string)
+			required[name] = true
+			if _, ok := value[name]; !ok {
+				v.fail(join(path, name), "required field is missing")
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		// sorted so the errors come out in the same order every time
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			// models like sending null for an optional field they have nothing for, decoding leaves it unset
+			if value[name] == nil && !required[name] {
+				continue
+			}
+			if property, ok := properties[name].(map[string]any); ok {
+				v.check(property, value[name], join(path, name))
+			}
+		}
+
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				v.check(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+// resolve looks up a "#/$defs/Name" reference in the root schema, the only kind SchemaRegistry makes
+func (v *validator) resolve(ref string) (map[string]any, bool) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, false
+	}
+	defs, _ := v.root["$defs"].(map[string]any)
+	schema, ok := defs[name].(map[string]any)
+	if !ok {
+		// Defs() hands out map[string]map[string]any, it may have been put in as is
+		typed, _ := v.root["$defs"].(map[string]map[string]any)
+		schema, ok = typed[name]
+	}
+	return schema, ok
+}
+
+// typeOf names the json type of a decoded value
+func typeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// typeMatches knows that every integer is a number, and that 3.0 counts as an integer
+func typeMatches(want, got string, value any) bool {
+	if want == got {
+		return true
+	}
+	if want == "integer" && got == "number" {
+		n, _ := value.(json.Number)
+		if _, err := n.Int64(); err == nil {
+			return true
+		}
+		f, err := n.Float64()
+		return err == nil && f == float64(int64(f))
+	}
+	return false
+}
+
+// sameValue compares an enum entry with a decoded value, numbers by value so 1 and 1.0 are equal
+func sameValue(allowed, value any) bool {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		switch a := allowed.(type) {
+		case float64:
+			return a == f
+		case int:
+			return float64(a) == f
+		case int64:
+			return float64(a) == f
+		}
+		return false
+	}
+	return jsonText(allowed) == jsonText(value)
+}
+
+func jsonText(value any) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// asSlice accepts both []string (what GenerateSchema writes) and []any (what decoding json gives)
+func asSlice(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case []string:
+		out := make([]any, len(v))
+		for i, s := range v {
+			out[i] = s
+		}
+		return out
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
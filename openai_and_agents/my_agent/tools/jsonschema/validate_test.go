@@ -0,0 +1,100 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+		Zip  int    `json:"zip,omitempty"`
+	}
+	type Args struct {
+		Name    string  `json:"name"`
+		Days    int     `json:"days"`
+		Budget  float64 `json:"budget,omitempty"`
+		Express bool    `json:"express,omitempty"`
+		Address Address `json:"address,omitempty"`
+	}
+	schema := GenerateSchema(reflect.TypeOf(Args{}))
+
+	cases := []struct {
+		name string
+		args string
+		want []string // substrings of the error, nil means valid
+	}{
+		{"valid", `{"name": "trip", "days": 3, "address": {"city": "Paris"}}`, nil},
+		{"integral float is an integer", `{"name": "trip", "days": 3.0}`, nil},
+		{"integer where a number goes", `{"name": "trip", "days": 3, "budget": 100}`, nil},
+		{"string for an integer", `{"name": "trip", "days": "3"}`, []string{"days: got string want integer"}},
+		{"fraction for an integer", `{"name": "trip", "days": 2.5}`, []string{"days: got number want integer"}},
+		{"missing required", `{"days": 3}`, []string{"name: required field is missing"}},
+		{"null for an optional field", `{"name": "trip", "days": 3, "budget": null, "address": null}`, nil},
+		{"null for a required field", `{"name": null, "days": 3}`, []string{"name: got null want string"}},
+		{"nested", `{"name": "trip", "days": 3, "address": {"zip": "75001"}}`, []string{"address.city: required field is missing", "address.zip: got string want integer"}},
+		{"not an object", `[1, 2]`, []string{"arguments: got array want object"}},
+		{"not json", `{"name": `, []string{"not valid json"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(schema, []byte(tc.args))
+			if tc.want == nil {
+				if err != nil {
+					t.Errorf("got %v want no error", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("got %q want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_EnumAndItems(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"unit":  map[string]any{"type": "string", "enum": []any{"celsius", "fahrenheit"}},
+			"level": map[string]any{"type": "integer", "enum": []any{1.0, 2.0}},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"home":  map[string]any{"$ref": "#/$defs/Place"},
+		},
+		"$defs": map[string]map[string]any{
+			"Place": {"type": "object", "required": []string{"city"}},
+		},
+	}
+
+	if err := Validate(schema, []byte(`{"unit": "celsius", "level": 2, "tags": ["a"], "home": {"city": "Paris"}}`)); err != nil {
+		t.Errorf("got %v want no error", err)
+	}
+
+	err := Validate(schema, []byte(`{"unit": "kelvin", "level": 3, "tags": ["a", 1], "home": {}}`))
+	for _, want := range []string{`unit: "kelvin" is not one of`, "level: 3 is not one of", "tags[1]: got number want string", "home.city: required field is missing"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestValidate_NullOptionalPointer(t *testing.T) {
+	type Args struct {
+		Days *int `json:"days,omitempty"`
+	}
+	schema := GenerateSchema(reflect.TypeOf(Args{}))
+
+	if err := Validate(schema, []byte(`{"days": null}`)); err != nil {
+		t.Errorf("got %v want null accepted for an optional pointer field", err)
+	}
+	if err := Validate(schema, []byte(`{"days": "3"}`)); err == nil {
+		t.Error("expected an error for a string in the optional field")
+	}
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"log"
+	"strings"
+)
+
+// WithLogger sends the registry's notes (like "had to clean up the arguments") to logger instead of the standard logger
+func WithLogger(logger *log.Logger) RegistryOption {
+	return func(r *Registry) {
+		r.logf = logger.Printf
+	}
+}
+
+// cleanArguments fixes the quirks weaker models add around otherwise fine json
+// it strips ```json fences and drops trailing commas before } or ]
+// it is only tried after a strict decode failed so good arguments never go through here
+func cleanArguments(args string) string {
+	args = stripFences(strings.TrimSpace(args))
+	return dropTrailingCommas(args)
+}
+
+// stripFences turns "```json\n{...}\n```" into "{...}"
+func stripFences(s string) string {
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+
+	s = strings.TrimPrefix(s, "```")
+	// the rest of the opening line is the language tag, if any
+	if newline := strings.IndexByte(s, '\n'); newline >= 0 {
+		s = s[newline+1:]
+	} else {
+		s = strings.TrimPrefix(s, "json")
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+// dropTrailingCommas removes a comma when the next non space character closes an object or array
+// it walks the string keeping track of whether it is inside a json string so "a,}" in a value is left alone
+func dropTrailingCommas(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			b.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		}
+
+		if c == ',' {
+			next := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if strings.HasPrefix(next, "}") || strings.HasPrefix(next, "]") {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_CallLenientArguments(t *testing.T) {
+	var logs bytes.Buffer
+	registry := NewRegistry(WithLogger(log.New(&logs, "", 0)))
+	registry.Register("get_weather", "Get current weather", GetWeather)
+
+	cases := []struct {
+		name string
+		args string
+	}{
+		{"markdown fence", "```json\n{\"city\": \"Paris\", \"days\": 2}\n```"},
+		{"bare fence", "```\n{\"city\": \"Paris\", \"days\": 2}\n```"},
+		{"trailing comma", `{"city": "Paris", "days": 2,}`},
+		{"trailing comma with spaces", "{\"city\": \"Paris\",\n \"days\": 2 ,\n}"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			logs.Reset()
+
+			got, err := registry.Call(context.Background(), "get_weather", tc.args)
+			if err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			if want := "Weather in Paris for 2 days is sunny"; got != want {
+				t.Errorf("got %q want %q", got, want)
+			}
+			if !strings.Contains(logs.String(), "lenient parsing") {
+				t.Errorf("expected the lenient parse to be logged, got %q", logs.String())
+			}
+		})
+	}
+
+	t.Run("commas inside strings are left alone", func(t *testing.T) {
+		if got := dropTrailingCommas(`{"city": "a,}", "days": 1,}`); got != `{"city": "a,}", "days": 1}` {
+			t.Errorf("got %s", got)
+		}
+	})
+
+	t.Run("clean arguments are not logged", func(t *testing.T) {
+		logs.Reset()
+		registry.Call(context.Background(), "get_weather", `{"city":"Paris","days":2}`)
+		if logs.Len() != 0 {
+			t.Errorf("got log %q want nothing", logs.String())
+		}
+	})
+
+	t.Run("garbage still fails", func(t *testing.T) {
+		if _, err := registry.Call(context.Background(), "get_weather", "not json at all"); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
@@ -1,9 +1,15 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"my_agent/llm"
 	"my_agent/tools/jsonschema"
 	"reflect"
+	"slices"
+	"sort"
+	"sync"
 )
 
 // Tool represents a registerable function.
@@ -16,48 +22,304 @@ type Tool struct {
 	Func reflect.Value
 	// this returns the datatype
 	// these reflect values tell the underlying value and type of function lets say WeatherTool()
+	// nil when the tool takes no arguments at all
 	ArgsType reflect.Type
 
 	// maps is a kv data store , something like dict , here the string is the key type and value can be any
 	Schema map[string]any
+
+	// Params is only set for functions taking plain arguments like func(city string, days int)
+	// ArgsType is then a struct built on the fly with one field per param, and Call spreads its fields back out in order
+	Params []string
+
+	// Retry overrides the registry wide retry policy for this tool, nil means use the registry one
+	Retry *RetryPolicy
 }
 
 type Registry struct {
+	// guards tools, a registry is usually shared by every request of a server
+	mu    sync.RWMutex
 	tools map[string]Tool
+
+	// default retry policy for every tool, the zero value means no retries
+	retry RetryPolicy
+
+	// where notes about tool calls go, log.Printf unless WithLogger says otherwise
+	logf func(format string, args ...any)
+
+	// what retry backoff waits on, see WithClock
+	clock llm.Clock
+
+	// try repairJSON on arguments nothing else could decode, see WithJSONRepair
+	repair bool
+
+	// reject argument fields the tool doesn't have, see WithValidateToolArgs
+	strict bool
+
+	// check arguments against the tool's schema before decoding, see WithValidateResponseSchema
+	validateSchema bool
 }
 
-func NewRegistry() *Registry {
-	return &Registry{
+// RegistryOption configures a Registry, same functional options idea as the agent
+type RegistryOption func(*Registry)
+
+// these are used to compare against the function signature, reflect.TypeOf on an interface needs the pointer trick
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
 		tools: make(map[string]Tool),
+		logf:  log.Printf,
+		clock: llm.RealClock{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
 	}
+
+	return r
 }
 
 // check if function -- get back its args -- generate json -- save it
+// supported shapes are func(Args), func() and both again with a leading context.Context
+// returning either string or (string, error)
+// a single struct is the recommended way, plain arguments like func(a string, b int) also work
+// but reflection cant see their names so they show up to the model as arg0, arg1 ...
 func (r *Registry) Register(name string, description string, function any) error {
+	return r.register(name, description, function, nil)
+}
+
+// RegisterWithParams is Register for functions taking plain arguments, params names them in order
+// so the model sees "city" and "days" instead of arg0 and arg1
+func (r *Registry) RegisterWithParams(name string, description string, function any, params ...string) error {
+	return r.register(name, description, function, params)
+}
+
+// MethodDescriber lets a struct passed to RegisterStruct describe its methods
+// reflection can't read doc comments so this is the only way to give them a description
+type MethodDescriber interface {
+	Describe(method string) string
+}
+
+// RegisterStruct registers every exported method of v shaped like func(Args) (string, error)
+// each one under prefix + the method name, other methods are skipped
+// pass a pointer if the methods have pointer receivers, otherwise reflection won't see them
+func (r *Registry) RegisterStruct(prefix string, v any) error {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return fmt.Errorf("RegisterStruct needs a value, got nil")
+	}
+
+	describer, _ := v.(MethodDescriber)
+
+	registered := 0
+	for i := 0; i < value.NumMethod(); i++ {
+		method := value.Type().Method(i)
+		// the bound method value already has the receiver baked in, so it looks like a plain func to register
+		bound := value.Method(i)
+		if !isToolMethod(bound.Type()) {
+			continue
+		}
+
+		description := ""
+		if describer != nil {
+			description = describer.Describe(method.Name)
+		}
+
+		if err := r.register(prefix+method.Name, description, bound.Interface(), nil); err != nil {
+			return fmt.Errorf("registering method %s: %w", method.Name, err)
+		}
+		registered++
+	}
+
+	if registered == 0 {
+		return fmt.Errorf("%T has no methods shaped like func(Args) (string, error)", v)
+	}
+	return nil
+}
+
+// isToolMethod matches func(Args) (string, error) where Args is a struct
+func isToolMethod(fnType reflect.Type) bool {
+	return fnType.NumIn() == 1 && isStruct(fnType.In(0)) &&
+		fnType.NumOut() == 2 && fnType.Out(0).Kind() == reflect.String && fnType.Out(1) == errorType
+}
+
+func (r *Registry) register(name string, description string, function any, paramNames []string) error {
 
 	fnType := reflect.TypeOf(function)
 
-	if fnType.Kind() != reflect.Func {
+	if fnType == nil || fnType.Kind() != reflect.Func {
 		return fmt.Errorf("this is not a valid function please try again")
 	}
 
-	if fnType.NumIn() != 1 {
-		return fmt.Errorf("function must have exactly 1 argument")
+	if err := checkReturns(fnType); err != nil {
+		return err
 	}
 
-	argType := fnType.In(0)
+	// skip over the context if there is one, what is left are the real arguments
+	numArgs := fnType.NumIn()
+	if takesContext(fnType) {
+		numArgs--
+	}
 
-	// Generate schema using our helper
-	schema := jsonschema.GenerateSchema(argType)
+	var argType reflect.Type
+	var schema map[string]any
+	var params []string
+
+	switch {
+	case numArgs == 0:
+		// nothing to fill in, the model just calls it with {}
+		schema = map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		}
+	case numArgs == 1 && isStruct(fnType.In(fnType.NumIn()-1)) && paramNames == nil:
+		argType = fnType.In(fnType.NumIn() - 1)
+		// Generate schema using our helper
+		schema = jsonschema.GenerateSchema(argType)
+	default:
+		var err error
+		argType, params, err = paramsStruct(fnType, paramNames)
+		if err != nil {
+			return err
+		}
+		schema = jsonschema.GenerateSchema(argType)
+	}
 
 	// Store the tool
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.tools[name] = Tool{
 		Name:        name,
 		Description: description,
 		Func:        reflect.ValueOf(function),
 		ArgsType:    argType,
 		Schema:      schema,
+		Params:      params,
 	}
 
 	return nil
 }
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// paramsStruct builds a struct type with one json tagged field per plain argument
+// so GenerateSchema and json.Unmarshal can treat func(a string, b int) exactly like func(Args)
+func paramsStruct(fnType reflect.Type, paramNames []string) (reflect.Type, []string, error) {
+	first := 0
+	if takesContext(fnType) {
+		first = 1
+	}
+	numArgs := fnType.NumIn() - first
+
+	if paramNames != nil && len(paramNames) != numArgs {
+		return nil, nil, fmt.Errorf("got %d param names for a function with %d arguments", len(paramNames), numArgs)
+	}
+
+	fields := make([]reflect.StructField, 0, numArgs)
+	params := make([]string, 0, numArgs)
+	for i := 0; i < numArgs; i++ {
+		param := fmt.Sprintf("arg%d", i)
+		if paramNames != nil {
+			param = paramNames[i]
+		}
+		if param == "" || slices.Contains(params, param) {
+			return nil, nil, fmt.Errorf("param names must be unique and not empty, got %q", param)
+		}
+		params = append(params, param)
+
+		// StructOf needs exported field names, the json tag is what the model actually sees
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("P%d", i),
+			Type: fnType.In(first + i),
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:"%s"`, param)),
+		})
+	}
+
+	return reflect.StructOf(fields), params, nil
+}
+
+// Definitions converts the registered tools into the wire format the chat request expects
+// sorted by name so the request body is the same every time
+func (r *Registry) Definitions() []llm.Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]llm.Tool, 0, len(names))
+	for _, name := range names {
+		tool := r.tools[name]
+		defs = append(defs, llm.Tool{
+			Type: "function",
+			Function: llm.FunctionDescription{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Schema,
+			},
+		})
+	}
+	return defs
+}
+
+// Clone returns a registry with the same tools and options that can be changed on its own
+// start every request from a shared base registry and register the tenant specific tools on the clone
+// Tool values are copied, their schemas are shared since nothing changes a schema after Register
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clone := &Registry{
+		tools:  make(map[string]Tool, len(r.tools)),
+		retry:  r.retry,
+		logf:   r.logf,
+		clock:  r.clock,
+		repair: r.repair,
+		strict: r.strict,
+
+		validateSchema: r.validateSchema,
+	}
+	for name, tool := range r.tools {
+		if tool.Retry != nil {
+			policy := *tool.Retry
+			tool.Retry = &policy
+		}
+		clone.tools[name] = tool
+	}
+	return clone
+}
+
+// lookup is the locked read of one tool
+func (r *Registry) lookup(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+func takesContext(fnType reflect.Type) bool {
+	return fnType.NumIn() > 0 && fnType.In(0) == contextType
+}
+
+// the model only understands text so the tool has to hand back a string, optionally with an error
+func checkReturns(fnType reflect.Type) error {
+	switch {
+	case fnType.NumOut() == 1 && fnType.Out(0).Kind() == reflect.String:
+		return nil
+	case fnType.NumOut() == 2 && fnType.Out(0).Kind() == reflect.String && fnType.Out(1) == errorType:
+		return nil
+	}
+	return fmt.Errorf("function must return string or (string, error)")
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -47,3 +48,93 @@ func TestRegistry_Register(t *testing.T) {
 		t.Error("Schema missing 'city' property")
 	}
 }
+
+type LookupArgs struct {
+	ID int `json:"id"`
+}
+
+type userService struct {
+	names map[int]string
+}
+
+func (s *userService) GetUser(args LookupArgs) (string, error) {
+	name, ok := s.names[args.ID]
+	if !ok {
+		return "", fmt.Errorf("no user %d", args.ID)
+	}
+	return name, nil
+}
+
+func (s *userService) CountUsers(args struct{}) (string, error) {
+	return fmt.Sprint(len(s.names)), nil
+}
+
+// not a tool shape, RegisterStruct should leave it alone
+func (s *userService) Reset() {}
+
+func (s *userService) Describe(method string) string {
+	if method == "GetUser" {
+		return "Look up a user by id"
+	}
+	return ""
+}
+
+func TestRegistry_RegisterStruct(t *testing.T) {
+	registry := NewRegistry()
+	svc := &userService{names: map[int]string{1: "ada", 2: "grace"}}
+
+	if err := registry.RegisterStruct("users_", svc); err != nil {
+		t.Fatalf("RegisterStruct failed: %v", err)
+	}
+
+	if len(registry.tools) != 2 {
+		t.Fatalf("got %d tools want 2 (GetUser and CountUsers)", len(registry.tools))
+	}
+
+	if got := registry.tools["users_GetUser"].Description; got != "Look up a user by id" {
+		t.Errorf("got description %q want the one from Describe", got)
+	}
+
+	got, err := registry.Call(context.Background(), "users_GetUser", `{"id":2}`)
+	if err != nil || got != "grace" {
+		t.Errorf("got (%q, %v) want (%q, nil)", got, err, "grace")
+	}
+
+	got, err = registry.Call(context.Background(), "users_CountUsers", `{}`)
+	if err != nil || got != "2" {
+		t.Errorf("got (%q, %v) want (%q, nil)", got, err, "2")
+	}
+
+	t.Run("no matching methods", func(t *testing.T) {
+		if err := NewRegistry().RegisterStruct("x_", struct{}{}); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+}
+
+func TestRegistry_Clone(t *testing.T) {
+	base := NewRegistry()
+	base.Register("get_weather", "Get current weather", GetWeather)
+
+	clone := base.Clone()
+	if err := clone.Register("get_time", "Get the time", func() string { return "noon" }); err != nil {
+		t.Fatalf("Register on the clone failed: %v", err)
+	}
+	clone.SetRetryPolicy("get_weather", RetryPolicy{MaxAttempts: 3})
+
+	if len(base.Definitions()) != 1 {
+		t.Errorf("got %d tools in the base registry want 1, the clone leaked into it", len(base.Definitions()))
+	}
+	if len(clone.Definitions()) != 2 {
+		t.Errorf("got %d tools in the clone want 2", len(clone.Definitions()))
+	}
+	if base.tools["get_weather"].Retry != nil {
+		t.Error("the retry policy set on the clone changed the base registry")
+	}
+
+	// the clone still runs the tools it inherited
+	got, err := clone.Call(context.Background(), "get_weather", `{"city":"Paris","days":1}`)
+	if err != nil || got != "Weather in Paris for 1 days is sunny" {
+		t.Errorf("got %q, %v from the inherited tool", got, err)
+	}
+}
@@ -0,0 +1,104 @@
+package tools
+
+import "strings"
+
+// WithJSONRepair adds a last resort repair step for arguments that are still broken after the lenient clean up
+// it quotes bare object keys, closes an unterminated string and balances braces and brackets
+// so a model that got cut off or forgot some quotes doesn't cost a whole round trip
+func WithJSONRepair() RegistryOption {
+	return func(r *Registry) {
+		r.repair = true
+	}
+}
+
+// repairJSON does a single pass over s tracking strings and open brackets
+// stray closers are dropped, bare keys like {city: "Paris"} get quoted
+// and whatever is still open at the end is closed in the right order
+func repairJSON(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+
+	var open []byte // '{' and '[' still waiting for their closer
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			b.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			open = append(open, c)
+		case c == '}' || c == ']':
+			if len(open) == 0 || open[len(open)-1] != matching(c) {
+				continue // nothing to close, drop it
+			}
+			open = open[:len(open)-1]
+		case isIdentStart(c) && len(open) > 0 && open[len(open)-1] == '{':
+			// a word followed by ':' inside an object is a key someone forgot to quote
+			end := i
+			for end < len(s) && isIdentPart(s[end]) {
+				end++
+			}
+			if strings.HasPrefix(strings.TrimLeft(s[end:], " \t\r\n"), ":") {
+				b.WriteString(`"` + s[i:end] + `"`)
+				i = end - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	out := b.String()
+	if inString {
+		if escaped {
+			out = out[:len(out)-1] // a lone backslash would escape our closing quote
+		}
+		out += `"`
+	}
+
+	// a cut off "a": 1, leaves a comma right before the closers we are about to add
+	out = strings.TrimRight(out, " \t\r\n")
+	out = strings.TrimSuffix(out, ",")
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out += string(closer(open[i]))
+	}
+	return dropTrailingCommas(out)
+}
+
+func matching(closer byte) byte {
+	if closer == '}' {
+		return '{'
+	}
+	return '['
+}
+
+func closer(opener byte) byte {
+	if opener == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
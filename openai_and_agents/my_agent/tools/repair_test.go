@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_CallJSONRepair(t *testing.T) {
+	cases := []struct {
+		name string
+		args string
+	}{
+		{"unbalanced brace", `{"city": "Paris", "days": 2`},
+		{"unquoted keys", `{city: "Paris", days: 2}`},
+		{"cut off inside a string", `{"days": 2, "city": "Paris`},
+		{"cut off after a comma", `{"city": "Paris", "days": 2, `},
+		{"stray closer", `{"city": "Paris", "days": 2}}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			registry := NewRegistry(WithJSONRepair(), WithLogger(log.New(&logs, "", 0)))
+			registry.Register("get_weather", "Get current weather", GetWeather)
+
+			got, err := registry.Call(context.Background(), "get_weather", tc.args)
+			if err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			if want := "Weather in Paris for 2 days is sunny"; got != want {
+				t.Errorf("got %q want %q", got, want)
+			}
+			if !strings.Contains(logs.String(), "repaired the arguments") {
+				t.Errorf("expected the repair to be logged, got %q", logs.String())
+			}
+		})
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register("get_weather", "Get current weather", GetWeather)
+
+		if _, err := registry.Call(context.Background(), "get_weather", `{city: "Paris", days: 2}`); err == nil {
+			t.Error("expected an error without WithJSONRepair")
+		}
+	})
+}
+
+func TestRepairJSON_LeavesStringsAlone(t *testing.T) {
+	// braces and words with colons inside values are not structure
+	in := `{"note": "use {city: x} and ]", "ok": true`
+	want := `{"note": "use {city: x} and ]", "ok": true}`
+	if got := repairJSON(in); got != want {
+		t.Errorf("got %s want %s", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"my_agent/llm"
+)
+
+// RetryPolicy decides how often a failing tool is tried again before the error goes back to the model
+// this is separate from the llm client retry, it is about flaky apis the tools themselves call
+type RetryPolicy struct {
+	// total tries including the first one, 0 or 1 means no retries
+	MaxAttempts int
+	Backoff     llm.Backoff
+}
+
+// only errors that say so are retried, a bad argument will fail the same way every time
+// tools can implement this on their own error types or wrap with Retryable
+type retryable interface {
+	Retryable() bool
+}
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string   { return e.err.Error() }
+func (e *retryableError) Unwrap() error   { return e.err }
+func (e *retryableError) Retryable() bool { return true }
+
+// Retryable marks a tool error as transient so Call tries the tool again
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+func isRetryable(err error) bool {
+	var r retryable
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// WithToolRetry sets the retry policy for every tool that does not have its own
+func WithToolRetry(policy RetryPolicy) RegistryOption {
+	return func(r *Registry) {
+		r.retry = policy
+	}
+}
+
+// WithClock sets the clock the retry backoff waits on, tests use a fake one to skip the real sleeping
+func WithClock(clock llm.Clock) RegistryOption {
+	return func(r *Registry) {
+		r.clock = clock
+	}
+}
+
+// SetRetryPolicy overrides the registry wide policy for one tool
+func (r *Registry) SetRetryPolicy(name string, policy RetryPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tool, ok := r.tools[name]
+	if !ok {
+		return fmt.Errorf("tool %q is not registered", name)
+	}
+	tool.Retry = &policy
+	r.tools[name] = tool
+	return nil
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"my_agent/llm"
+	"testing"
+	"time"
+)
+
+func TestRegistry_CallRetries(t *testing.T) {
+	quickRetry := RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     llm.Backoff{BaseDelay: time.Millisecond, Multiplier: 2, MaxDelay: 5 * time.Millisecond},
+	}
+
+	t.Run("flaky tool fails twice then succeeds", func(t *testing.T) {
+		registry := NewRegistry(WithToolRetry(quickRetry))
+
+		calls := 0
+		registry.Register("flaky", "", func() (string, error) {
+			calls++
+			if calls < 3 {
+				return "", Retryable(errors.New("upstream 503"))
+			}
+			return "finally", nil
+		})
+
+		got, err := registry.Call(context.Background(), "flaky", "{}")
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if got != "finally" || calls != 3 {
+			t.Errorf("got (%q, %d calls) want (%q, 3 calls)", got, calls, "finally")
+		}
+	})
+
+	t.Run("non retryable errors are not retried", func(t *testing.T) {
+		registry := NewRegistry(WithToolRetry(quickRetry))
+
+		calls := 0
+		registry.Register("broken", "", func() (string, error) {
+			calls++
+			return "", errors.New("bad argument")
+		})
+
+		if _, err := registry.Call(context.Background(), "broken", "{}"); err == nil {
+			t.Fatal("expected an error but didn't get one")
+		}
+		if calls != 1 {
+			t.Errorf("got %d calls want 1", calls)
+		}
+	})
+
+	t.Run("backoff waits on the registry clock", func(t *testing.T) {
+		slowRetry := RetryPolicy{MaxAttempts: 3, Backoff: llm.Backoff{BaseDelay: time.Minute, Multiplier: 2}}
+		clock := &countingClock{}
+		registry := NewRegistry(WithToolRetry(slowRetry), WithClock(clock))
+
+		registry.Register("down", "", func() (string, error) {
+			return "", Retryable(errors.New("still down"))
+		})
+
+		// two one minute plus waits, the fake clock lets them pass at once
+		registry.Call(context.Background(), "down", "{}")
+		if clock.waited != 3*time.Minute {
+			t.Errorf("got %v waited want 3m (1m + 2m)", clock.waited)
+		}
+	})
+
+	t.Run("per tool policy overrides the registry one", func(t *testing.T) {
+		registry := NewRegistry()
+
+		calls := 0
+		registry.Register("flaky", "", func() (string, error) {
+			calls++
+			return "", Retryable(errors.New("still down"))
+		})
+		registry.SetRetryPolicy("flaky", RetryPolicy{MaxAttempts: 2})
+
+		_, err := registry.Call(context.Background(), "flaky", "{}")
+		if err == nil || calls != 2 {
+			t.Errorf("got (%v, %d calls) want an error after 2 calls", err, calls)
+		}
+	})
+}
+
+// countingClock adds up the waits instead of sleeping through them
+type countingClock struct {
+	waited time.Duration
+}
+
+func (c *countingClock) Now() time.Time { return time.Time{}.Add(c.waited) }
+
+func (c *countingClock) After(d time.Duration) <-chan time.Time {
+	c.waited += d
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func (c *countingClock) Sleep(d time.Duration) { c.waited += d }
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Describe renders every registered tool as a plain markdown list
+// smaller models tend to follow a natural language tool list better than a raw json schema
+// so this is meant to be dropped straight into the system prompt
+// each tool is a "- name: description" line followed by one indented line per param
+func (r *Registry) Describe() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// maps have no order so sort the names, otherwise the prompt changes every run
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		tool := r.tools[name]
+
+		fmt.Fprintf(&b, "- %s", tool.Name)
+		if tool.Description != "" {
+			fmt.Fprintf(&b, ": %s", tool.Description)
+		}
+		b.WriteString("\n")
+
+		describeParams(&b, tool.Schema)
+	}
+
+	return b.String()
+}
+
+// describeParams writes one indented line per property of an object schema
+func describeParams(b *strings.Builder, schema map[string]any) {
+	props, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]string)
+
+	params := make([]string, 0, len(props))
+	for param := range props {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	for _, param := range params {
+		propSchema, _ := props[param].(map[string]any)
+
+		kind, _ := propSchema["type"].(string)
+		if kind == "" {
+			kind = "any"
+		}
+
+		requirement := "optional"
+		if slices.Contains(required, param) {
+			requirement = "required"
+		}
+
+		fmt.Fprintf(b, "  - %s (%s, %s)", param, kind, requirement)
+		if desc, ok := propSchema["description"].(string); ok && desc != "" {
+			fmt.Fprintf(b, ": %s", desc)
+		}
+		b.WriteString("\n")
+	}
+}
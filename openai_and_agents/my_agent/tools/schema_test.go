@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+type SearchArgs struct {
+	Query string `json:"query" description:"What to search for"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+func Search(args SearchArgs) string {
+	return "results for " + args.Query
+}
+
+func TestRegistry_Describe(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.Register("get_weather", "Get current weather", GetWeather); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+	if err := registry.Register("search", "Search the web", Search); err != nil {
+		t.Fatalf("Failed to register tool: %v", err)
+	}
+
+	got := registry.Describe()
+
+	// every tool name and its required params should be in there
+	for _, want := range []string{
+		"- get_weather: Get current weather",
+		"city (string, required): The city name to check weather for",
+		"days (integer, required)",
+		"- search: Search the web",
+		"query (string, required): What to search for",
+		"limit (integer, optional)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Describe() missing %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"my_agent/tools/jsonschema"
+	"strings"
+)
+
+// WithValidateToolArgs makes Call reject arguments with fields the tool doesn't have
+// by default an extra field the model made up is silently dropped, in strict mode the error
+// names it (json: unknown field "foo") so the model can fix the call on the next turn
+// plain argument tools are covered too, their fields are the param names
+func WithValidateToolArgs() RegistryOption {
+	return func(r *Registry) {
+		r.strict = true
+	}
+}
+
+// unmarshalArgs is json.Unmarshal, with DisallowUnknownFields in strict mode
+func (r *Registry) unmarshalArgs(args string, v any) error {
+	if !r.strict {
+		return json.Unmarshal([]byte(args), v)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(args))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// WithValidateResponseSchema checks the arguments of every call against the tool's json schema
+// (types, enums, required fields) before the tool runs
+// json.Unmarshal alone is forgiving: a missing field becomes its zero value and the caller never knows,
+// this catches it and the error lists every violation, the agent feeds it back to the model as a tool error
+func WithValidateResponseSchema() RegistryOption {
+	return func(r *Registry) {
+		r.validateSchema = true
+	}
+}
+
+// checkSchema is the WithValidateResponseSchema check, on the arguments as decodeArgs will see them
+// arguments that are not json even after clean up are left to decodeArgs to report
+func (r *Registry) checkSchema(tool Tool, args string) error {
+	if !r.validateSchema || tool.Schema == nil {
+		return nil
+	}
+
+	text := args
+	if !json.Valid([]byte(text)) {
+		text = cleanArguments(args)
+	}
+	if !json.Valid([]byte(text)) && r.repair {
+		text = repairJSON(text)
+	}
+	if !json.Valid([]byte(text)) {
+		return nil
+	}
+
+	if err := jsonschema.Validate(tool.Schema, []byte(text)); err != nil {
+		return fmt.Errorf("arguments for tool %q don't match its schema: %w", tool.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WithValidateToolArgs(t *testing.T) {
+	args := `{"city": "Paris", "days": 2, "foo": "bar"}`
+
+	t.Run("strict rejects the unknown field", func(t *testing.T) {
+		registry := NewRegistry(WithValidateToolArgs())
+		registry.Register("get_weather", "Get current weather", GetWeather)
+
+		_, err := registry.Call(context.Background(), "get_weather", args)
+		if err == nil {
+			t.Fatal("expected an error for the extra field")
+		}
+		if !strings.Contains(err.Error(), `"foo"`) {
+			t.Errorf("got %q want the error to name the field", err)
+		}
+
+		// the known fields alone still work
+		if _, err := registry.Call(context.Background(), "get_weather", `{"city": "Paris", "days": 2}`); err != nil {
+			t.Errorf("valid arguments failed: %v", err)
+		}
+	})
+
+	t.Run("lenient by default", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register("get_weather", "Get current weather", GetWeather)
+
+		got, err := registry.Call(context.Background(), "get_weather", args)
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if want := "Weather in Paris for 2 days is sunny"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+}
+
+func TestRegistry_WithValidateResponseSchema(t *testing.T) {
+	t.Run("string for an integer", func(t *testing.T) {
+		registry := NewRegistry(WithValidateResponseSchema())
+		registry.Register("get_weather", "Get current weather", GetWeather)
+
+		_, err := registry.Call(context.Background(), "get_weather", `{"city": "Paris", "days": "two"}`)
+		if err == nil || !strings.Contains(err.Error(), "days: got string want integer") {
+			t.Errorf("got %v want the type mismatch on days", err)
+		}
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		registry := NewRegistry(WithValidateResponseSchema())
+		registry.Register("get_weather", "Get current weather", GetWeather)
+
+		// plain json.Unmarshal would happily run the tool with days = 0
+		_, err := registry.Call(context.Background(), "get_weather", `{"city": "Paris"}`)
+		if err == nil || !strings.Contains(err.Error(), "days: required field is missing") {
+			t.Errorf("got %v want days reported missing", err)
+		}
+	})
+
+	t.Run("valid arguments after clean up", func(t *testing.T) {
+		registry := NewRegistry(WithValidateResponseSchema())
+		registry.Register("get_weather", "Get current weather", GetWeather)
+
+		got, err := registry.Call(context.Background(), "get_weather", "```json\n{\"city\": \"Paris\", \"days\": 2}\n```")
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+		if want := "Weather in Paris for 2 days is sunny"; got != want {
+			t.Errorf("got %q want %q", got, want)
+		}
+	})
+}